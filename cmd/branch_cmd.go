@@ -0,0 +1,394 @@
+package cmd
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+func pruneBranchOpts(c *common.Config) []CmdOpt {
+	opts := repoInfoOpts(c)
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"y", "yes"},
+			Label:    "dont ask for confirmation [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+	return opts
+}
+
+func (ctx *CmdCtx) PruneBranchCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := pruneBranchOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	yes := opts[2].Val.Bool
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	branches, err := getLocalBranches()
+	if err != nil {
+		return err
+	}
+
+	prs, err := repoCtx.ListAllPR(&gitea.ListPRRequest{
+		State: "all",
+	})
+	if err != nil {
+		return err
+	}
+
+	// most recent pr for every head branch
+	latest := make(map[string]gitea.PullRequest)
+	for i := range prs {
+		// branches of forks arent ours to prune
+		if prs[i].IsCrossRepo() {
+			continue
+		}
+		p, e := latest[prs[i].Head.Ref]
+		if !e || prs[i].Number > p.Number {
+			latest[prs[i].Head.Ref] = prs[i]
+		}
+	}
+
+	stale := make([]gitea.PullRequest, 0, len(branches))
+	for i := range branches {
+		pr, e := latest[branches[i]]
+		if !e || pr.State != gitea.Closed {
+			continue
+		}
+		stale = append(stale, pr)
+		state := "closed"
+		if pr.Merged {
+			state = "merged"
+		}
+		fmt.Printf("%s\tPR index=%d %s, title=%s\n", pr.Head.Ref, pr.Number, state, pr.Title)
+	}
+
+	if len(stale) == 0 {
+		fmt.Println("nothing to prune")
+		return nil
+	}
+
+	if !yes && !confirm(fmt.Sprintf("delete %d local branch(es)?", len(stale))) {
+		return nil
+	}
+
+	for i := range stale {
+		if err := cleanupLocalBranch(stale[i].Head.Ref, stale[i].Base.Ref, stale[i].Head.Sha); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ctx *CmdCtx) ListBranchCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := repoInfoOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	branches, err := repoCtx.ListAllBranches()
+	if err != nil {
+		return err
+	}
+
+	for i := range branches {
+		c := &branches[i].Commit
+		sha := c.ID
+		if len(sha) > 10 {
+			sha = sha[:10]
+		}
+		msg := strings.SplitN(c.Message, "\n", 2)[0]
+		fmt.Printf("branch: %s protected=%t, commit=%s, author=%s, date=%s, msg=%s\n",
+			branches[i].Name, branches[i].Protected,
+			sha, c.Author.Name, c.Timestamp, msg)
+	}
+
+	return nil
+}
+
+func createBranchOpts(c *common.Config) []CmdOpt {
+	opts := repoInfoOpts(c)
+
+	// 2
+	opts = append(opts, addOptWithDefaultVal("branch name", "new branch", []string{"n", "name"}, ""))
+
+	// 3
+	def := ""
+	if c != nil {
+		def = c.Gitea.DefaultBaseForPR
+	}
+	opts = append(opts, addOptWithDefaultVal("from ref   ", "branch, tag or commit", []string{"f", "from"}, def))
+
+	return opts
+}
+
+func (ctx *CmdCtx) CreateBranchCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := createBranchOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	name := opts[2].Val.Str
+	from := opts[3].Val.Str
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	fmt.Printf("creating branch %s from %s\n", name, from)
+
+	b, err := repoCtx.CreateBranch(&gitea.CreateBranchRequest{
+		Opt: gitea.CreateBranchRepoOption{
+			NewBranchName: name,
+			OldRefName:    from,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("branch: %s commit=%s\n", b.Name, b.Commit.ID)
+
+	return nil
+}
+
+func deleteBranchOpts(c *common.Config) []CmdOpt {
+	opts := repoInfoOpts(c)
+
+	// 2
+	opts = append(opts, addOptWithDefaultVal("branch name", "branch to delete", []string{"n", "name"}, ""))
+
+	// 3
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"force"},
+			Label:    "delete even if open pull requests use the branch [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"y", "yes"},
+			Label:    "dont ask for confirmation [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) DeleteBranchCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := deleteBranchOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	name := opts[2].Val.Str
+	force := opts[3].Val.Bool
+	yes := opts[4].Val.Bool
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	prs, err := repoCtx.ListAllPR(&gitea.ListPRRequest{
+		State: "open",
+	})
+	if err != nil {
+		return err
+	}
+
+	used := false
+	for i := range prs {
+		if (prs[i].Head.Ref == name && !prs[i].IsCrossRepo()) || prs[i].Base.Ref == name {
+			used = true
+			fmt.Printf("PR: %s->%s index=%d, title=%s, url=%s\n",
+				prs[i].HeadName(), prs[i].Base.Ref,
+				prs[i].Number, prs[i].Title, prs[i].Url)
+		}
+	}
+
+	if used && !force {
+		return fmt.Errorf("branch %s is used by open pull requests, use --force to delete it anyway", name)
+	}
+
+	if !yes && !confirm(fmt.Sprintf("delete branch %s from %s/%s?", name, repoCtx.Owner, repoCtx.Repo)) {
+		return nil
+	}
+
+	return repoCtx.DeleteBranch(&gitea.DeleteBranchRequest{
+		Branch: name,
+	})
+}
+
+func viewBranchProtectionOpts(c *common.Config) []CmdOpt {
+	opts := repoInfoOpts(c)
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"n", "name"},
+			Label:    "only print rule with this name [default: all]",
+			NoPrompt: true,
+			Optional: true,
+		},
+	})
+	return opts
+}
+
+func (ctx *CmdCtx) ViewBranchProtectionCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := viewBranchProtectionOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	name := opts[2].Val.Str
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	rules, err := repoCtx.ListBranchProtections()
+	if err != nil {
+		return err
+	}
+
+	if name != "" {
+		filtered := make([]gitea.BranchProtection, 0, 1)
+		for i := range rules {
+			if rules[i].Name() == name {
+				filtered = append(filtered, rules[i])
+			}
+		}
+		if len(filtered) == 0 {
+			return fmt.Errorf("rule %s not found", name)
+		}
+		rules = filtered
+	}
+
+	b, err := yaml.Marshal(rules)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(string(b))
+
+	return nil
+}
+
+func editBranchProtectionOpts(c *common.Config) []CmdOpt {
+	opts := repoInfoOpts(c)
+
+	// 2
+	opts = append(opts, addOptWithDefaultVal("spec file  ", "yaml list of rules", []string{"f", "file"}, ""))
+
+	// 3
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"d", "dry"},
+			Label:    "dry run [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	return opts
+}
+
+func readBranchProtectionSpec(path string) ([]gitea.BranchProtection, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []gitea.BranchProtection
+	if err := yaml.Unmarshal(b, &rules); err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		if rules[i].Name() == "" {
+			return nil, fmt.Errorf("%s: rule %d has no rule_name", path, i)
+		}
+	}
+	return rules, nil
+}
+
+func (ctx *CmdCtx) EditBranchProtectionCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := editBranchProtectionOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	file := opts[2].Val.Str
+	dry := opts[3].Val.Bool
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	spec, err := readBranchProtectionSpec(file)
+	if err != nil {
+		return err
+	}
+
+	changes, err := planBranchProtection(repoCtx, spec, false)
+	if err != nil {
+		return err
+	}
+
+	printProtectionPlan(changes, "")
+
+	if dry {
+		return nil
+	}
+
+	return applyBranchProtection(repoCtx, changes)
+}
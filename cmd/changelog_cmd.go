@@ -0,0 +1,342 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const defaultChangelogTemplate = `## {{.Title}} ({{.Date}})
+{{range .Sections}}
+### {{.Title}}
+
+{{range .PRs}}- {{.Title}} (#{{.Number}}) @{{.User.Login}}
+{{end}}{{end}}`
+
+type changelogSection struct {
+	Title string
+	PRs   []gitea.PullRequest
+}
+
+// data passed to changelog template
+type changelogData struct {
+	Title    string
+	From     string
+	To       string
+	Date     string
+	Sections []changelogSection
+}
+
+func defaultChangelogConfig() common.Changelog {
+	return common.Changelog{
+		Sections: []common.ChangelogSection{
+			{Title: "Breaking", Labels: []string{"breaking"}, Bump: bumpMajor},
+			{Title: "Features", Labels: []string{"feature", "enhancement"}, Bump: bumpMinor},
+			{Title: "Fixes", Labels: []string{"fix", "bug"}, Bump: bumpPatch},
+		},
+		Other:       "Other",
+		DefaultBump: bumpPatch,
+	}
+}
+
+func getChangelogConfig(c *common.Config) common.Changelog {
+	if c == nil || len(c.Changelog.Sections) == 0 {
+		d := defaultChangelogConfig()
+		if c != nil {
+			d.Template = c.Changelog.Template
+			if c.Changelog.DefaultBump != "" {
+				d.DefaultBump = c.Changelog.DefaultBump
+			}
+		}
+		return d
+	}
+	return c.Changelog
+}
+
+// most recent tag reachable from ref, empty if there is none
+func latestTag(ref string) string {
+	t, err := gitOutput("describe", "--tags", "--abbrev=0", ref)
+	if err != nil {
+		return ""
+	}
+	return t
+}
+
+// same as latestTag but skips tag pointing at ref itself
+func previousTag(ref string) string {
+	return latestTag(ref + "^")
+}
+
+// prs merged into repository whose merge commits are in from..to
+func getMergedPrsBetween(repoCtx *gitea.RepoCtx, from, to string) ([]gitea.PullRequest, error) {
+	rev := to
+	if from != "" {
+		rev = from + ".." + to
+	}
+	out, err := gitOutput("rev-list", rev)
+	if err != nil {
+		return nil, fmt.Errorf("couldnt list commits %s in local repository: %v", rev, err)
+	}
+	commits := make(map[string]bool)
+	for _, sha := range strings.Fields(out) {
+		commits[sha] = true
+	}
+
+	// pr is updated when merged, so once prs are older than from nothing merged after it follows
+	var fromDate time.Time
+	if from != "" {
+		d, err := gitOutput("log", "-1", "--format=%cI", from)
+		if err != nil {
+			return nil, fmt.Errorf("couldnt get date of %s: %v", from, err)
+		}
+		fromDate, _ = time.Parse(time.RFC3339, d)
+	}
+
+	prs, err := repoCtx.ListPRUntil(&gitea.ListPRRequest{
+		State: string(gitea.Closed),
+		Sort:  "recentupdate",
+	}, func(pr *gitea.PullRequest) bool {
+		t, err := time.Parse(time.RFC3339, pr.UpdatedAt)
+		return err == nil && t.Before(fromDate)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var res []gitea.PullRequest
+	for i := range prs {
+		if prs[i].Merged && commits[prs[i].MergeCommitSha] {
+			res = append(res, prs[i])
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].MergedAt < res[j].MergedAt
+	})
+
+	return res, nil
+}
+
+// group prs into configured sections, empty sections are dropped
+func groupChangelog(cfg common.Changelog, prs []gitea.PullRequest) []changelogSection {
+	sections := make([]changelogSection, len(cfg.Sections))
+	var other []gitea.PullRequest
+
+	for i := range cfg.Sections {
+		sections[i].Title = cfg.Sections[i].Title
+	}
+
+outer:
+	for _, pr := range prs {
+		for i := range cfg.Sections {
+			for _, l := range pr.Labels {
+				if containsFold(cfg.Sections[i].Labels, l.Name) {
+					sections[i].PRs = append(sections[i].PRs, pr)
+					continue outer
+				}
+			}
+		}
+		other = append(other, pr)
+	}
+
+	if cfg.Other != "" {
+		sections = append(sections, changelogSection{
+			Title: cfg.Other,
+			PRs:   other,
+		})
+	}
+
+	var res []changelogSection
+	for _, s := range sections {
+		if len(s.PRs) > 0 {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+func containsFold(list []string, s string) bool {
+	for _, x := range list {
+		if strings.EqualFold(x, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func renderChangelog(tmplPath string, data *changelogData) (string, error) {
+	text := defaultChangelogTemplate
+	if tmplPath != "" {
+		b, err := ioutil.ReadFile(tmplPath)
+		if err != nil {
+			return "", err
+		}
+		text = string(b)
+	}
+
+	tmpl, err := template.New("changelog").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// render changelog of prs merged between from and to, from defaults to previous tag
+func buildChangelog(c *common.Config, repoCtx *gitea.RepoCtx, from, to, title, tmplPath string) (string, error) {
+	if to == "" {
+		to = "HEAD"
+	}
+	if from == "" {
+		from = previousTag(to)
+	}
+	if title == "" {
+		title = to
+	}
+
+	cfg := getChangelogConfig(c)
+	if tmplPath == "" {
+		tmplPath = cfg.Template
+	}
+
+	prs, err := getMergedPrsBetween(repoCtx, from, to)
+	if err != nil {
+		return "", err
+	}
+
+	return renderChangelog(tmplPath, &changelogData{
+		Title:    title,
+		From:     from,
+		To:       to,
+		Date:     time.Now().Format("2006-01-02"),
+		Sections: groupChangelog(cfg, prs),
+	})
+}
+
+// insert entry at the top of changelog file, below its main heading if there is one
+func prependChangelog(path, entry string) error {
+	old, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	head, rest := "", string(old)
+	if strings.HasPrefix(rest, "# ") {
+		if i := strings.Index(rest, "\n"); i >= 0 {
+			head, rest = rest[:i+1]+"\n", strings.TrimLeft(rest[i+1:], "\n")
+		} else {
+			head, rest = rest+"\n\n", ""
+		}
+	}
+
+	entry = strings.TrimRight(entry, "\n") + "\n"
+	if rest != "" {
+		entry += "\n"
+	}
+
+	return ioutil.WriteFile(path, []byte(head+entry+rest), 0644)
+}
+
+func changelogOpts(c *common.Config) []CmdOpt {
+	// 0-1
+	opts := repoInfoOpts(c)
+
+	// 2
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"from"},
+			Label:    "start ref, exclusive [default: previous tag]",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 3
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"to"},
+			Label:    "end ref [default: HEAD]",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"t", "title"},
+			Label:    "changelog entry title [default: end ref]",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 5
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"template"},
+			Label:    "go template file [default: changelog.template from config or markdown]",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 6
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"output"},
+			Label:    "prepend entry to this file, e.g. CHANGELOG.md, instead of printing it",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) ChangelogCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := changelogOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	output := opts[6].Val.Str
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	cl, err := buildChangelog(ctx.Config, repoCtx,
+		opts[2].Val.Str, opts[3].Val.Str, opts[4].Val.Str, opts[5].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		fmt.Print(cl)
+		return nil
+	}
+
+	if err := prependChangelog(output, cl); err != nil {
+		return err
+	}
+	fmt.Printf("updated %s\n", output)
+
+	return nil
+}
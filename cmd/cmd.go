@@ -4,9 +4,9 @@ import (
 	"fmt"
 	"gitea-cli/common"
 	"gitea-cli/gitea"
-	"gitea-cli/rocketchat"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 )
 
@@ -76,7 +76,8 @@ func repoInfoOpts(config *common.Config) []CmdOpt {
 }
 
 func listPrOpts(config *common.Config) []CmdOpt {
-	return repoInfoOpts(config)
+	opts := repoInfoOpts(config)
+	return append(opts, formatOpt())
 }
 
 func (ctx *CmdCtx) ListPrCommand() error {
@@ -90,6 +91,11 @@ func (ctx *CmdCtx) ListPrCommand() error {
 	}
 	owner := opts[0].Val.Str
 	repo := opts[1].Val.Str
+	format := opts[2].Val.Str
+
+	if err := checkFormat(format, formatText, formatJson); err != nil {
+		return err
+	}
 
 	repoCtx := gitea.RepoCtx{
 		Token:  ctx.Config.Gitea.TokenSha1,
@@ -105,9 +111,13 @@ func (ctx *CmdCtx) ListPrCommand() error {
 		return err
 	}
 
+	if format == formatJson {
+		return printJson(prs)
+	}
+
 	for i := range prs {
 		fmt.Printf("PR: %s->%s index=%d, title=%s, user=%s, url=%s\n",
-			prs[i].Head.Ref, prs[i].Base.Ref,
+			prs[i].HeadName(), prs[i].Base.Ref,
 			prs[i].Number, prs[i].Title, prs[i].User.Login,
 			prs[i].Url)
 	}
@@ -161,18 +171,7 @@ func newPrOpts(c *common.Config) []CmdOpt {
 	})
 
 	// 7
-	optional := !c.Rocketchat.Enabled || c.Rocketchat.DefaultNotifyChannel != ""
-	ret = append(ret, CmdOpt{
-		Spec: CmdOptSpec{
-			ArgFlags: []string{"notify"},
-			Label:    "Notification channel for rocketchat",
-			NoPrompt: optional,
-			Optional: optional,
-			DefaultStrFunc: func() (string, error) {
-				return c.Rocketchat.DefaultNotifyChannel, nil
-			},
-		},
-	})
+	ret = append(ret, notifyChannelOpt(c))
 
 	// 8
 	ret = append(ret, CmdOpt{
@@ -206,25 +205,65 @@ func newPrOpts(c *common.Config) []CmdOpt {
 		},
 	})
 
+	// 11
+	ret = append(ret, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"p", "push"},
+			Label:    "push head branch without asking if its missing or behind on remote [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	// 12
+	ret = append(ret, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"x", "fixes"},
+			Label:    "comma separated issues closed by this PR [default: issue number from head branch name]",
+			NoPrompt: true,
+			Optional: true,
+		},
+	})
+
+	// 13
+	ret = append(ret, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"noparent"},
+			Label:    "when repo is a fork open PR in the fork itself instead of its parent [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
 	return ret
 }
 
-func (ctx *CmdCtx) notifyRocketchatAboutPr(prOpts []CmdOpt, pr *gitea.PullRequest, head, base string, merged bool) error {
-	rctx := rocketchat.Ctx{
-		ApiUrl: ctx.Config.Rocketchat.ToApiUrl(),
-		UserID: ctx.Config.Rocketchat.UserID,
-		Token:  ctx.Config.Rocketchat.Token,
+/*
+when repository is a fork returns context of its parent
+and head prefixed with owner of the fork,
+otherwise repoCtx and head are returned unchanged
+*/
+func prTarget(repoCtx *gitea.RepoCtx, head string) (*gitea.RepoCtx, string, error) {
+	r, err := repoCtx.GetRepo()
+	if err != nil {
+		return nil, "", err
 	}
+	if !r.Fork || r.Parent == nil {
+		return repoCtx, head, nil
+	}
+	parent := &gitea.RepoCtx{
+		Owner:  r.Parent.Owner.Login,
+		Repo:   r.Parent.Name,
+		Token:  repoCtx.Token,
+		ApiUrl: repoCtx.ApiUrl,
+	}
+	return parent, repoCtx.Owner + ":" + head, nil
+}
 
-	targetChan := prOpts[7].Val.Str
-
+func (ctx *CmdCtx) notifyAboutPr(targets []notifyTarget, channels notifyChannels, prOpts []CmdOpt, pr *gitea.PullRequest, head, base string, merged bool) error {
 	noHdr := prOpts[8].Val.Bool
 	msg := ""
 
-	if ctx.Config.Rocketchat.DefaultHeader != "" && !noHdr {
-		msg += ctx.Config.Rocketchat.DefaultHeader
-	}
-
 	if merged {
 		msg += fmt.Sprintf("Creating and Merging PR: [%s](%s) (*%s* -> *%s*)", pr.Title, pr.Url, head, base)
 	} else {
@@ -236,12 +275,7 @@ func (ctx *CmdCtx) notifyRocketchatAboutPr(prOpts []CmdOpt, pr *gitea.PullReques
 		msg += fmt.Sprintf("\n%s", footer)
 	}
 
-	_, err := rctx.PostMessage(&rocketchat.PostMsgRequest{
-		Channel: targetChan,
-		Text:    msg,
-	})
-
-	return err
+	return postNotification(targets, channels, msg, !noHdr)
 }
 
 func (ctx *CmdCtx) NewPrCommand() error {
@@ -254,6 +288,16 @@ func (ctx *CmdCtx) NewPrCommand() error {
 		return err
 	}
 
+	// bad notify config should fail before anything is created or merged
+	targets, err := newNotifiers(ctx.Config)
+	if err != nil {
+		return err
+	}
+	channels, err := parseNotifyChannels(ctx.Config, opts[7].Val.Str)
+	if err != nil {
+		return err
+	}
+
 	owner := opts[0].Val.Str
 	repo := opts[1].Val.Str
 	head := opts[2].Val.Str
@@ -270,10 +314,15 @@ func (ctx *CmdCtx) NewPrCommand() error {
 		title = "WIP: " + title
 	}
 
-	fmt.Printf("Creating pr for %s/%s %s->%s with title: '%s'\n", owner, repo, head, base, title)
+	// head given explicitly as owner:branch
+	branch := head
+	if i := strings.Index(head, ":"); i >= 0 {
+		branch = head[i+1:]
+	}
 
-	if dry {
-		return nil
+	push := opts[11].Val.Bool
+	if err := ensureBranchPushed(branch, push, dry); err != nil {
+		return err
 	}
 
 	repoCtx := gitea.RepoCtx{
@@ -286,14 +335,36 @@ func (ctx *CmdCtx) NewPrCommand() error {
 		return err
 	}
 
+	// repository where pr is opened, differs from repoCtx for forks
+	targetCtx := &repoCtx
+	if !opts[13].Val.Bool && branch == head {
+		var err error
+		if targetCtx, head, err = prTarget(&repoCtx, head); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Creating pr for %s/%s %s->%s with title: '%s'\n",
+		targetCtx.Owner, targetCtx.Repo, head, base, title)
+
+	fixes, err := getFixedIssues(targetCtx, opts[12].Val.Str, branch)
+	if err != nil {
+		return err
+	}
+
+	if dry {
+		return nil
+	}
+
 	var req = gitea.CreatePRRequest{
 		Opt: gitea.CreatePullRequestOption{
 			Base:  base,
 			Head:  head,
 			Title: title,
+			Body:  closingKeywords(fixes),
 		},
 	}
-	pr, err := repoCtx.CreatePR(&req)
+	pr, err := targetCtx.CreatePR(&req)
 	if err != nil {
 		return err
 	}
@@ -309,27 +380,23 @@ func (ctx *CmdCtx) NewPrCommand() error {
 			},
 			Index: pr.Number,
 		}
-		if err := repoCtx.MergePR(&mergeReq); err != nil {
+		if err := targetCtx.MergePR(&mergeReq); err != nil {
 			return err
 		}
-		if err := repoCtx.DeleteBranch(&gitea.DeleteBranchRequest{
+		if err := prHeadCtx(targetCtx, pr).DeleteBranch(&gitea.DeleteBranchRequest{
 			Branch: pr.Head.Ref,
 		}); err != nil {
 			return err
 		}
-
-		if ctx.Config.Rocketchat.Enabled {
-			return ctx.notifyRocketchatAboutPr(opts, pr, head, base, true)
+		// pr is merged already, rest of the cleanup should still happen
+		if err := closeLinkedIssues(targetCtx, pr); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: couldnt close linked issues: %v\n", err)
 		}
 
-		return nil
-	}
-
-	if ctx.Config.Rocketchat.Enabled {
-		return ctx.notifyRocketchatAboutPr(opts, pr, head, base, false)
+		return ctx.notifyAboutPr(targets, channels, opts, pr, head, base, true)
 	}
 
-	return nil
+	return ctx.notifyAboutPr(targets, channels, opts, pr, head, base, false)
 }
 
 func findPrOpts() []CmdOpt {
@@ -337,21 +404,60 @@ func findPrOpts() []CmdOpt {
 		{
 			Spec: CmdOptSpec{
 				ArgFlags: []string{"t", "title"},
-				Label:    "PR title (current branch name if empty)",
+				Label:    "PR title or head branch, owner:branch for forks (current branch name if empty)",
 				DefaultStrFunc: func() (string, error) {
 					return getBranch(), nil
 				},
 				NoPrompt: true,
 			},
+		}, {
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"i", "index"},
+				Label:    "PR index, takes precedence over title",
+				Optional: true,
+				NoPrompt: true,
+			},
 		},
 	}
 }
 
-func findPr(repoCtx *gitea.RepoCtx, title string) (gitea.PullRequest, error) {
+// context of repository holding head branch of pr
+func prHeadCtx(repoCtx *gitea.RepoCtx, pr *gitea.PullRequest) *gitea.RepoCtx {
+	if !pr.IsCrossRepo() || pr.Head.Repo == nil {
+		return repoCtx
+	}
+	return &gitea.RepoCtx{
+		Owner:  pr.Head.Repo.Owner.Login,
+		Repo:   pr.Head.Repo.Name,
+		Token:  repoCtx.Token,
+		ApiUrl: repoCtx.ApiUrl,
+	}
+}
+
+/*
+find pr by index if its set,
+otherwise look for open pr with given title or head branch,
+head of pr from fork can be given as owner:branch
+*/
+func findPr(repoCtx *gitea.RepoCtx, title, index string) (gitea.PullRequest, error) {
+	if index != "" {
+		i, err := strconv.Atoi(strings.TrimPrefix(index, "#"))
+		if err != nil {
+			return gitea.PullRequest{}, fmt.Errorf("invalid pr index: %s", index)
+		}
+		pr, err := repoCtx.GetPR(&gitea.GetPRRequest{
+			Index: i,
+		})
+		if err != nil {
+			return gitea.PullRequest{}, err
+		}
+		return *pr, nil
+	}
+
 	req := gitea.ListPRRequest{
 		State: "open",
 	}
-	prs, err := repoCtx.ListPR(&req)
+	prs, err := repoCtx.ListAllPR(&req)
 	if err != nil {
 		return gitea.PullRequest{}, err
 	}
@@ -362,6 +468,19 @@ func findPr(repoCtx *gitea.RepoCtx, title string) (gitea.PullRequest, error) {
 		}
 	}
 
+	for i := range prs {
+		if prs[i].HeadName() == title {
+			return prs[i], nil
+		}
+	}
+
+	// bare branch name of pr from fork
+	for i := range prs {
+		if prs[i].Head.Ref == title {
+			return prs[i], nil
+		}
+	}
+
 	return gitea.PullRequest{}, fmt.Errorf("pr not found")
 }
 
@@ -387,16 +506,14 @@ func mergePrOpts(c *common.Config) []CmdOpt {
 		},
 	})
 
-	optional := !c.Rocketchat.Enabled || c.Rocketchat.DefaultNotifyChannel != ""
+	opts = append(opts, notifyChannelOpt(c))
 	opts = append(opts, CmdOpt{
 		Spec: CmdOptSpec{
-			ArgFlags: []string{"notify"},
-			Label:    "Notification channel for rocketchat",
-			NoPrompt: optional,
-			Optional: optional,
-			DefaultStrFunc: func() (string, error) {
-				return c.Rocketchat.DefaultNotifyChannel, nil
-			},
+			ArgFlags: []string{"l", "local"},
+			Label:    "Switch to base branch, pull it and remove local head branch",
+			Optional: true,
+			NoPrompt: true,
+			IsBool:   true,
 		},
 	})
 
@@ -413,13 +530,23 @@ func (ctx *CmdCtx) MergePrCommand() error {
 		return err
 	}
 
+	// bad notify config should fail before anything is created or merged
+	targets, err := newNotifiers(ctx.Config)
+	if err != nil {
+		return err
+	}
+	channels, err := parseNotifyChannels(ctx.Config, opts[6].Val.Str)
+	if err != nil {
+		return err
+	}
+
 	owner := opts[0].Val.Str
 	repo := opts[1].Val.Str
 	title := opts[2].Val.Str
-	rm := opts[3].Val.Bool
-	force := opts[4].Val.Bool
-
-	var err error
+	selIndex := opts[3].Val.Str
+	rm := opts[4].Val.Bool
+	force := opts[5].Val.Bool
+	local := opts[7].Val.Bool
 
 	repoCtx := gitea.RepoCtx{
 		Token:  ctx.Config.Gitea.TokenSha1,
@@ -430,7 +557,7 @@ func (ctx *CmdCtx) MergePrCommand() error {
 
 	fmt.Printf("merging pr with title: '%s'\n", title)
 
-	pr, err := findPr(&repoCtx, title)
+	pr, err := findPr(&repoCtx, title, selIndex)
 	if err != nil {
 		return err
 	}
@@ -447,52 +574,32 @@ func (ctx *CmdCtx) MergePrCommand() error {
 		return err
 	}
 
+	// pr is merged already, rest of the cleanup should still happen
+	if err := closeLinkedIssues(&repoCtx, &pr); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: couldnt close linked issues: %v\n", err)
+	}
+
 	if !rm {
-		var tmp string
-	O:
-		for {
-			fmt.Printf("remove branch %s? [y/n]: ", pr.Head.Ref)
-			fmt.Scanln(&tmp)
-			switch tmp {
-			case "y":
-				rm = true
-				break O
-			case "n":
-				break O
-			}
-		}
+		rm = confirm(fmt.Sprintf("remove branch %s?", pr.HeadName()))
 	}
 
 	if rm {
-		if err := repoCtx.DeleteBranch(&gitea.DeleteBranchRequest{
+		if err := prHeadCtx(&repoCtx, &pr).DeleteBranch(&gitea.DeleteBranchRequest{
 			Branch: pr.Head.Ref,
 		}); err != nil {
 			return err
 		}
 	}
 
-	if ctx.Config.Rocketchat.Enabled {
-		rctx := rocketchat.Ctx{
-			ApiUrl: ctx.Config.Rocketchat.ToApiUrl(),
-			UserID: ctx.Config.Rocketchat.UserID,
-			Token:  ctx.Config.Rocketchat.Token,
-		}
-
-		targetChan := opts[5].Val.Str
-
-		_, err = rctx.PostMessage(&rocketchat.PostMsgRequest{
-			Channel: targetChan,
-			Text: fmt.Sprintf(`
-			[%s](%s) (*%s* -> *%s*) has been merged
-		`, pr.Title, pr.Url, pr.Head.Ref, pr.Base.Ref),
-		})
-
-		if err != nil {
+	if local {
+		if err := cleanupLocalBranch(pr.Head.Ref, pr.Base.Ref, pr.Head.Sha); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return postNotification(targets, channels, fmt.Sprintf(`
+			[%s](%s) (*%s* -> *%s*) has been merged
+		`, pr.Title, pr.Url, pr.HeadName(), pr.Base.Ref), false)
 }
 
 //
@@ -537,9 +644,10 @@ func (ctx *CmdCtx) ClosePrCommand() error {
 	owner := opts[0].Val.Str
 	repo := opts[1].Val.Str
 	title := opts[2].Val.Str
+	selIndex := opts[3].Val.Str
 
-	close := opts[3].Val.Bool
-	rename := opts[4].Val.Str
+	close := opts[4].Val.Bool
+	rename := opts[5].Val.Str
 
 	var err error
 
@@ -552,7 +660,7 @@ func (ctx *CmdCtx) ClosePrCommand() error {
 
 	fmt.Printf("updating pr with title: '%s'\n", title)
 
-	pr, err := findPr(&repoCtx, title)
+	pr, err := findPr(&repoCtx, title, selIndex)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,347 @@
+package cmd
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"os"
+	"sort"
+	"strings"
+)
+
+var permissionRank = map[string]int{
+	"read":  1,
+	"write": 2,
+	"admin": 3,
+	"owner": 4,
+}
+
+func checkPermission(p string) error {
+	switch p {
+	case "read", "write", "admin":
+		return nil
+	}
+	return fmt.Errorf("invalid permission %s, expected read, write or admin", p)
+}
+
+func listCollabOpts(c *common.Config) []CmdOpt {
+	return append(repoInfoOpts(c), formatOpt())
+}
+
+func (ctx *CmdCtx) ListCollabCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := listCollabOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	format := opts[2].Val.Str
+	if err := checkFormat(format, formatText, formatJson); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	users, err := repoCtx.ListAllCollaborators()
+	if err != nil {
+		return err
+	}
+
+	perms := make([]*gitea.RepoPermission, len(users))
+	for i := range users {
+		if perms[i], err = repoCtx.GetCollaboratorPermission(&gitea.GetCollaboratorPermissionRequest{
+			Username: users[i].Login,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if format == formatJson {
+		return printJson(perms)
+	}
+
+	for _, p := range perms {
+		fmt.Printf("collaborator: %s permission=%s, full name=%s\n", p.User.Login, p.Permission, p.User.FullName)
+	}
+
+	return nil
+}
+
+func addCollabOpts(c *common.Config) []CmdOpt {
+	opts := repoInfoOpts(c)
+	opts = append(opts, addOptWithDefaultVal("users      ", "comma separated usernames", []string{"u", "users"}, ""))
+	return append(opts, addOptWithDefaultVal("permission ", "read, write or admin", []string{"p", "permission"}, "write"))
+}
+
+func (ctx *CmdCtx) AddCollabCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := addCollabOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	perm := opts[3].Val.Str
+	if err := checkPermission(perm); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	for _, u := range splitList(opts[2].Val.Str) {
+		if err := repoCtx.AddCollaborator(&gitea.AddCollaboratorRequest{
+			Username: u,
+			Opt: gitea.AddCollaboratorOption{
+				Permission: perm,
+			},
+		}); err != nil {
+			return fmt.Errorf("%s: %v", u, err)
+		}
+		fmt.Printf("%s has %s access to %s/%s\n", u, perm, repoCtx.Owner, repoCtx.Repo)
+	}
+
+	return nil
+}
+
+func removeCollabOpts(c *common.Config) []CmdOpt {
+	opts := repoInfoOpts(c)
+	return append(opts, addOptWithDefaultVal("users      ", "comma separated usernames", []string{"u", "users"}, ""))
+}
+
+func (ctx *CmdCtx) RemoveCollabCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := removeCollabOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	for _, u := range splitList(opts[2].Val.Str) {
+		if err := repoCtx.DeleteCollaborator(&gitea.DeleteCollaboratorRequest{
+			Username: u,
+		}); err != nil {
+			return fmt.Errorf("%s: %v", u, err)
+		}
+		fmt.Printf("removed %s from %s/%s\n", u, repoCtx.Owner, repoCtx.Repo)
+	}
+
+	return nil
+}
+
+func collabPermissionOpts(c *common.Config) []CmdOpt {
+	opts := repoInfoOpts(c)
+	return append(opts, addOptWithDefaultVal("user       ", "", []string{"u", "user"}, ""))
+}
+
+func (ctx *CmdCtx) CollabPermissionCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := collabPermissionOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	p, err := repoCtx.GetCollaboratorPermission(&gitea.GetCollaboratorPermissionRequest{
+		Username: opts[2].Val.Str,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s has %s access to %s/%s (role: %s)\n", p.User.Login, p.Permission, repoCtx.Owner, repoCtx.Repo, p.RoleName)
+
+	return nil
+}
+
+type accessEntry struct {
+	Repo string `json:"repo"`
+	User string `json:"user"`
+	// effective permission, highest of all sources
+	Permission string `json:"permission"`
+	// comma separated sources: collaborator, team:<name>
+	Via string `json:"via"`
+}
+
+// highest of team permission and its unit permissions, teams may grant write only through units
+func teamPermission(t *gitea.Team) string {
+	res := string(t.Permission)
+	for _, p := range t.UnitsMap {
+		if permissionRank[p] > permissionRank[res] {
+			res = p
+		}
+	}
+	return res
+}
+
+/*
+who has at least minPerm access to repository and through what.
+permission is the effective one reported by gitea,
+so access granted by both collaboration and team is not understated.
+*/
+func repoAccess(repoCtx *gitea.RepoCtx, gctx *gitea.Ctx, teamMembers map[int][]gitea.User, minPerm int) ([]accessEntry, error) {
+	name := repoCtx.Owner + "/" + repoCtx.Repo
+
+	var order []string
+	sources := make(map[string][]string)
+	addSource := func(user, src string) {
+		if _, e := sources[user]; !e {
+			order = append(order, user)
+		}
+		sources[user] = append(sources[user], src)
+	}
+
+	users, err := repoCtx.ListAllCollaborators()
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		addSource(users[i].Login, "collaborator")
+	}
+
+	teams, err := repoCtx.ListRepoTeams()
+	if err != nil {
+		return nil, err
+	}
+	for i := range teams {
+		t := &teams[i]
+		// teams which dont grant reported permission arent its source
+		if rank := permissionRank[teamPermission(t)]; rank == 0 || rank < minPerm {
+			continue
+		}
+		members, e := teamMembers[t.ID]
+		if !e {
+			if members, err = gctx.ListAllTeamMembers(t.ID); err != nil {
+				return nil, err
+			}
+			teamMembers[t.ID] = members
+		}
+		for j := range members {
+			addSource(members[j].Login, "team:"+t.Name)
+		}
+	}
+
+	var res []accessEntry
+	for _, u := range order {
+		p, err := repoCtx.GetCollaboratorPermission(&gitea.GetCollaboratorPermissionRequest{
+			Username: u,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", u, err)
+		}
+		if permissionRank[p.Permission] < minPerm {
+			continue
+		}
+		res = append(res, accessEntry{name, u, p.Permission, strings.Join(sources[u], ",")})
+	}
+
+	return res, nil
+}
+
+func accessAuditOpts(c *common.Config) []CmdOpt {
+	return []CmdOpt{
+		// 0
+		orgOpt(c),
+		// 1
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"a", "all"},
+				Label:    "include read access [default: only write and admin]",
+				NoPrompt: true,
+				IsBool:   true,
+			},
+		},
+		// 2
+		formatOptOf(formatText, formatJson, formatCsv),
+	}
+}
+
+func (ctx *CmdCtx) AccessAuditCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := accessAuditOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	org := opts[0].Val.Str
+	format := opts[2].Val.Str
+	if err := checkFormat(format, formatText, formatJson, formatCsv); err != nil {
+		return err
+	}
+
+	minPerm := permissionRank["write"]
+	if opts[1].Val.Bool {
+		minPerm = permissionRank["read"]
+	}
+
+	gctx := ctx.newGiteaCtx()
+	if err := gctx.Validate(); err != nil {
+		return err
+	}
+
+	repos, err := gctx.ListAllOwnerRepos(org)
+	if err != nil {
+		return err
+	}
+
+	var entries []accessEntry
+	teamMembers := make(map[int][]gitea.User)
+	for i := range repos {
+		e, err := repoAccess(gctx.RepoCtx(repos[i].Owner.Login, repos[i].Name), gctx, teamMembers, minPerm)
+		if err != nil {
+			return fmt.Errorf("%s: %v", repos[i].FullName, err)
+		}
+		entries = append(entries, e...)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Repo != entries[j].Repo {
+			return entries[i].Repo < entries[j].Repo
+		}
+		return entries[i].User < entries[j].User
+	})
+
+	switch format {
+	case formatJson:
+		return printJson(entries)
+	case formatCsv:
+		rows := make([][]string, len(entries))
+		for i, e := range entries {
+			rows[i] = []string{e.Repo, e.User, e.Permission, e.Via}
+		}
+		return printCsv([]string{"repo", "user", "permission", "via"}, rows)
+	default:
+		for _, e := range entries {
+			fmt.Printf("%s\t%s\t%s\t%s\n", e.Repo, e.User, e.Permission, e.Via)
+		}
+	}
+
+	return nil
+}
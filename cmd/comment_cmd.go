@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+func commentPrOpts(c *common.Config) []CmdOpt {
+	// 0-3
+	opts := prSelectorOpts(c)
+	// 4-6
+	return append(opts, bodyOpts()...)
+}
+
+func (ctx *CmdCtx) CommentPrCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := commentPrOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	pr, err := findPr(repoCtx, opts[2].Val.Str, opts[3].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	hint := fmt.Sprintf("comment on PR #%d: %s", pr.Number, pr.Title)
+	body, err := getBody(opts[4:], true, hint)
+	if err != nil {
+		return err
+	}
+
+	c, err := repoCtx.CreateIssueComment(&gitea.CreateIssueCommentRequest{
+		Index: pr.Number,
+		Opt: gitea.CreateIssueCommentOption{
+			Body: body,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", c.HtmlUrl)
+
+	return nil
+}
+
+/*
+parse --since value, accepts durations relative to now (eg. 2h, 30m),
+dates (2006-01-02) and RFC3339 timestamps
+*/
+func parseSince(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %s, expected duration, date or RFC3339", s)
+}
+
+// single entry of pr conversation
+type threadEntry struct {
+	// unique within the thread
+	Key    string
+	Time   time.Time
+	Author string
+	What   string
+	Body   string
+}
+
+// issue comments, reviews and review comments of pr
+func getPrThread(repoCtx *gitea.RepoCtx, index int, since time.Time) ([]threadEntry, error) {
+	req := gitea.ListIssueCommentsRequest{
+		Index: index,
+	}
+	if !since.IsZero() {
+		req.Since = since.Format(time.RFC3339)
+	}
+	comments, err := repoCtx.ListIssueComments(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []threadEntry
+
+	for i := range comments {
+		c := &comments[i]
+		t, _ := time.Parse(time.RFC3339, c.CreatedAt)
+		res = append(res, threadEntry{
+			Key:    fmt.Sprintf("c%d", c.ID),
+			Time:   t,
+			Author: c.User.Login,
+			What:   "commented",
+			Body:   c.Body,
+		})
+	}
+
+	reviews, err := repoCtx.ListReviews(&gitea.ListReviewsRequest{
+		Index: index,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range reviews {
+		r := &reviews[i]
+		if r.State == gitea.ReviewPending || r.State == gitea.ReviewRequestReview {
+			continue
+		}
+		t, _ := time.Parse(time.RFC3339, r.SubmittedAt)
+		// comments of a review are posted with it
+		if t.Before(since) {
+			continue
+		}
+		res = append(res, threadEntry{
+			Key:    fmt.Sprintf("r%d", r.ID),
+			Time:   t,
+			Author: r.User.Login,
+			What:   fmt.Sprintf("reviewed (%s)", r.State),
+			Body:   r.Body,
+		})
+
+		rc, err := repoCtx.ListReviewComments(&gitea.ListReviewCommentsRequest{
+			Index:    index,
+			ReviewID: r.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for j := range rc {
+			t, _ := time.Parse(time.RFC3339, rc[j].CreatedAt)
+			line := rc[j].Position
+			if line == 0 {
+				line = rc[j].OriginalPosition
+			}
+			res = append(res, threadEntry{
+				Key:    fmt.Sprintf("rc%d", rc[j].ID),
+				Time:   t,
+				Author: rc[j].User.Login,
+				What:   fmt.Sprintf("commented on %s:%d", rc[j].Path, line),
+				Body:   rc[j].Body,
+			})
+		}
+	}
+
+	filtered := res[:0]
+	for i := range res {
+		if !res[i].Time.Before(since) {
+			filtered = append(filtered, res[i])
+		}
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Time.Before(filtered[j].Time)
+	})
+
+	return filtered, nil
+}
+
+func printThreadEntry(e *threadEntry) {
+	fmt.Printf("[%s] %s %s:\n", e.Time.Local().Format("2006-01-02 15:04"), e.Author, e.What)
+	if e.Body != "" {
+		fmt.Printf("\t%s\n", strings.ReplaceAll(e.Body, "\n", "\n\t"))
+	}
+	fmt.Println()
+}
+
+func listCommentsOpts(c *common.Config) []CmdOpt {
+	// 0-3
+	opts := prSelectorOpts(c)
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"s", "since"},
+			Label:    "only newer comments, duration (eg. 24h), date or RFC3339",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 5
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"f", "follow"},
+			Label:    "keep polling for new comments [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	// 6
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"interval"},
+			Label:    "poll interval for --follow [default: 30s]",
+			NoPrompt: true,
+			DefaultStrFunc: func() (string, error) {
+				return "30s", nil
+			},
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) ListCommentsCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := listCommentsOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	follow := opts[5].Val.Bool
+
+	var since time.Time
+	if opts[4].Val.Str != "" {
+		var err error
+		since, err = parseSince(opts[4].Val.Str)
+		if err != nil {
+			return err
+		}
+	}
+
+	interval, err := time.ParseDuration(opts[6].Val.Str)
+	if err != nil || interval <= 0 {
+		return fmt.Errorf("invalid interval: %s", opts[6].Val.Str)
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	pr, err := findPr(repoCtx, opts[2].Val.Str, opts[3].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	// entries at since are fetched again, seen drops them
+	seen := make(map[string]bool)
+
+	for {
+		entries, err := getPrThread(repoCtx, pr.Number, since)
+		if err != nil {
+			return err
+		}
+
+		for i := range entries {
+			if seen[entries[i].Key] {
+				continue
+			}
+			seen[entries[i].Key] = true
+			printThreadEntry(&entries[i])
+			if entries[i].Time.After(since) {
+				since = entries[i].Time
+			}
+		}
+
+		if !follow {
+			return nil
+		}
+
+		time.Sleep(interval)
+	}
+}
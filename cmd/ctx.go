@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"gitea-cli/common"
+	"gitea-cli/gitea"
 	"io/ioutil"
 	"os"
 
@@ -29,6 +30,22 @@ func (ctx *CmdCtx) ValidateConfig(withCred bool) error {
 	return ctx.Config.Validate(withCred)
 }
 
+func (ctx *CmdCtx) newRepoCtx(owner, repo string) *gitea.RepoCtx {
+	return &gitea.RepoCtx{
+		Token:  ctx.Config.Gitea.TokenSha1,
+		Owner:  owner,
+		Repo:   repo,
+		ApiUrl: ctx.Config.Gitea.ToApiUrl(),
+	}
+}
+
+func (ctx *CmdCtx) newGiteaCtx() *gitea.Ctx {
+	return &gitea.Ctx{
+		Token:  ctx.Config.Gitea.TokenSha1,
+		ApiUrl: ctx.Config.Gitea.ToApiUrl(),
+	}
+}
+
 type commandPathInfo struct {
 	Path    string
 	Command *Command
@@ -184,6 +201,395 @@ func NewCtx() (*CmdCtx, error) {
 		Handler: ctx.ClosePrCommand,
 		Opts:    updatePrOpts(ctx.Config),
 	}, "update", "pr")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Remove local branches whose pull requests were merged or closed",
+		Handler: ctx.PruneBranchCommand,
+		Opts:    pruneBranchOpts(ctx.Config),
+	}, "branch", "prune")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "List remote branches",
+		Handler: ctx.ListBranchCommand,
+		Opts:    repoInfoOpts(ctx.Config),
+	}, "branch", "list")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Create remote branch",
+		Handler: ctx.CreateBranchCommand,
+		Opts:    createBranchOpts(ctx.Config),
+	}, "branch", "create")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Delete remote branch which isnt used by open pull requests",
+		Handler: ctx.DeleteBranchCommand,
+		Opts:    deleteBranchOpts(ctx.Config),
+	}, "branch", "delete")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Print branch protection rules as yaml",
+		Handler: ctx.ViewBranchProtectionCommand,
+		Opts:    viewBranchProtectionOpts(ctx.Config),
+	}, "branch", "protection")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Create or update branch protection rules from yaml spec",
+		Handler: ctx.EditBranchProtectionCommand,
+		Opts:    editBranchProtectionOpts(ctx.Config),
+	}, "branch", "protection", "edit")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Reconcile branch protection rules of many repositories with yaml spec",
+		Handler: ctx.ProtectApplyCommand,
+		Opts:    protectApplyOpts(),
+	}, "protect", "apply")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Record chain of dependent branches",
+		Handler: ctx.SetStackCommand,
+		Opts:    setStackOpts(ctx.Config),
+	}, "stack", "set")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Show layers of the stack and their pull requests",
+		Handler: ctx.ShowStackCommand,
+		Opts:    stackOpts(ctx.Config),
+	}, "stack", "show")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Create pull request for every layer of the stack",
+		Handler: ctx.NewStackPrCommand,
+		Opts:    newStackPrOpts(ctx.Config),
+	}, "stack", "pr")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Drop merged layers, retarget and rebase the rest",
+		Handler: ctx.SyncStackCommand,
+		Opts:    stackOpts(ctx.Config),
+	}, "stack", "sync")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Approve, request changes or comment on pull request",
+		Handler: ctx.ReviewPrCommand,
+		Opts:    reviewPrOpts(ctx.Config),
+	}, "review", "pr")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "List reviews of pull request",
+		Handler: ctx.ListReviewsCommand,
+		Opts:    prSelectorOpts(ctx.Config),
+	}, "reviews", "pr")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Dismiss review of pull request",
+		Handler: ctx.DismissReviewCommand,
+		Opts:    dismissReviewOpts(ctx.Config),
+	}, "dismiss", "review")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Comment on pull request",
+		Handler: ctx.CommentPrCommand,
+		Opts:    commentPrOpts(ctx.Config),
+	}, "comment", "pr")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Print comments and reviews of pull request",
+		Handler: ctx.ListCommentsCommand,
+		Opts:    listCommentsOpts(ctx.Config),
+	}, "comments", "pr")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Create new issue",
+		Handler: ctx.NewIssueCommand,
+		Opts:    newIssueOpts(ctx.Config),
+	}, "new", "issue")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "List issues",
+		Handler: ctx.ListIssueCommand,
+		Opts:    listIssueOpts(ctx.Config),
+	}, "list", "issue")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Print issue with its comments",
+		Handler: ctx.ViewIssueCommand,
+		Opts:    viewIssueOpts(ctx.Config),
+	}, "view", "issue")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Edit existing issue",
+		Handler: ctx.UpdateIssueCommand,
+		Opts:    updateIssueOpts(ctx.Config),
+	}, "update", "issue")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Close issue",
+		Handler: ctx.CloseIssueCommand,
+		Opts:    updateIssueOpts(ctx.Config),
+	}, "close", "issue")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Reopen issue",
+		Handler: ctx.ReopenIssueCommand,
+		Opts:    updateIssueOpts(ctx.Config),
+	}, "reopen", "issue")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Comment on issue",
+		Handler: ctx.CommentIssueCommand,
+		Opts:    commentIssueOpts(ctx.Config),
+	}, "comment", "issue")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "List labels",
+		Handler: ctx.ListLabelCommand,
+		Opts:    listLabelOpts(ctx.Config),
+	}, "list", "label")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Create label",
+		Handler: ctx.NewLabelCommand,
+		Opts:    newLabelOpts(ctx.Config),
+	}, "new", "label")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Edit label",
+		Handler: ctx.UpdateLabelCommand,
+		Opts:    updateLabelOpts(ctx.Config),
+	}, "update", "label")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Delete label",
+		Handler: ctx.DeleteLabelCommand,
+		Opts:    deleteLabelOpts(ctx.Config),
+	}, "delete", "label")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Make labels of repositories match yaml spec",
+		Handler: ctx.SyncLabelCommand,
+		Opts:    syncLabelOpts(ctx.Config),
+	}, "sync", "label")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "List milestones",
+		Handler: ctx.ListMilestoneCommand,
+		Opts:    listMilestoneOpts(ctx.Config),
+	}, "list", "milestone")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Create milestone",
+		Handler: ctx.NewMilestoneCommand,
+		Opts:    newMilestoneOpts(ctx.Config),
+	}, "new", "milestone")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Edit, close or reopen milestone",
+		Handler: ctx.UpdateMilestoneCommand,
+		Opts:    updateMilestoneOpts(ctx.Config),
+	}, "update", "milestone")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Delete milestone",
+		Handler: ctx.DeleteMilestoneCommand,
+		Opts:    deleteMilestoneOpts(ctx.Config),
+	}, "delete", "milestone")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Create release, tag is created if its missing",
+		Handler: ctx.NewReleaseCommand,
+		Opts:    newReleaseOpts(ctx.Config),
+	}, "new", "release")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "List releases",
+		Handler: ctx.ListReleaseCommand,
+		Opts:    listReleaseOpts(ctx.Config),
+	}, "list", "release")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Compute next version from labels of prs merged since latest version tag",
+		Handler: ctx.NextReleaseCommand,
+		Opts:    nextReleaseOpts(ctx.Config),
+	}, "next", "release")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Publish draft release",
+		Handler: ctx.PublishReleaseCommand,
+		Opts:    releaseSelectorOpts(ctx.Config),
+	}, "publish", "release")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Delete release",
+		Handler: ctx.DeleteReleaseCommand,
+		Opts:    deleteReleaseOpts(ctx.Config),
+	}, "delete", "release")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Upload assets to release",
+		Handler: ctx.UploadReleaseCommand,
+		Opts:    uploadReleaseOpts(ctx.Config),
+	}, "upload", "release")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "List assets of release",
+		Handler: ctx.ListAssetCommand,
+		Opts:    listAssetOpts(ctx.Config),
+	}, "list", "asset")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Delete asset of release",
+		Handler: ctx.DeleteAssetCommand,
+		Opts:    deleteAssetOpts(ctx.Config),
+	}, "delete", "asset")
+
+	root.AddChainAnyOrder(&Command{
+		Desc:    "List repositories of user or organization",
+		Handler: ctx.ListRepoCommand,
+		Opts:    listRepoOpts(ctx.Config),
+	}, "list", "repo")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Search repositories visible to current user",
+		Handler: ctx.SearchRepoCommand,
+		Opts:    searchRepoOpts(ctx.Config),
+	}, "search", "repo")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Show repository details",
+		Handler: ctx.ViewRepoCommand,
+		Opts:    viewRepoOpts(ctx.Config),
+	}, "view", "repo")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Create repository for user or organization",
+		Handler: ctx.NewRepoCommand,
+		Opts:    newRepoOpts(ctx.Config),
+	}, "new", "repo")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Fork repository",
+		Handler: ctx.ForkRepoCommand,
+		Opts:    forkRepoOpts(ctx.Config),
+	}, "fork", "repo")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Clone repository, forks get upstream remote",
+		Handler: ctx.CloneRepoCommand,
+		Opts:    cloneRepoOpts(ctx.Config),
+	}, "clone", "repo")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Archive repository",
+		Handler: ctx.ArchiveRepoCommand,
+		Opts:    repoInfoOpts(ctx.Config),
+	}, "archive", "repo")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Unarchive repository",
+		Handler: ctx.UnarchiveRepoCommand,
+		Opts:    repoInfoOpts(ctx.Config),
+	}, "unarchive", "repo")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Delete repository",
+		Handler: ctx.DeleteRepoCommand,
+		Opts:    repoInfoOpts(ctx.Config),
+	}, "delete", "repo")
+
+	root.AddChainAnyOrder(&Command{
+		Desc:    "List organizations",
+		Handler: ctx.ListOrgCommand,
+		Opts:    listOrgOpts(ctx.Config),
+	}, "list", "org")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "List teams of organization",
+		Handler: ctx.ListTeamCommand,
+		Opts:    listTeamOpts(ctx.Config),
+	}, "list", "team")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Create team in organization",
+		Handler: ctx.NewTeamCommand,
+		Opts:    newTeamOpts(ctx.Config),
+	}, "new", "team")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "List members of team",
+		Handler: ctx.TeamMembersCommand,
+		Opts:    teamListOpts(ctx.Config),
+	}, "team", "members")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "List repositories team has access to",
+		Handler: ctx.TeamReposCommand,
+		Opts:    teamListOpts(ctx.Config),
+	}, "team", "repos")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Add users to team",
+		Handler: ctx.AddTeamMemberCommand,
+		Opts:    teamMemberOpts(ctx.Config),
+	}, "team", "add-member")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Remove users from team",
+		Handler: ctx.RemoveTeamMemberCommand,
+		Opts:    teamMemberOpts(ctx.Config),
+	}, "team", "remove-member")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Give team access to repositories",
+		Handler: ctx.AddTeamRepoCommand,
+		Opts:    teamRepoOpts(ctx.Config),
+	}, "team", "add-repo")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Revoke team access to repositories",
+		Handler: ctx.RemoveTeamRepoCommand,
+		Opts:    teamRepoOpts(ctx.Config),
+	}, "team", "remove-repo")
+
+	root.AddChainAnyOrder(&Command{
+		Desc:    "List collaborators of repository with their permissions",
+		Handler: ctx.ListCollabCommand,
+		Opts:    listCollabOpts(ctx.Config),
+	}, "list", "collab")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Add collaborators or change their permission",
+		Handler: ctx.AddCollabCommand,
+		Opts:    addCollabOpts(ctx.Config),
+	}, "add", "collab")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Remove collaborators",
+		Handler: ctx.RemoveCollabCommand,
+		Opts:    removeCollabOpts(ctx.Config),
+	}, "remove", "collab")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Show effective permission of user",
+		Handler: ctx.CollabPermissionCommand,
+		Opts:    collabPermissionOpts(ctx.Config),
+	}, "permission", "collab")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "List who has write or admin access to repositories of organization",
+		Handler: ctx.AccessAuditCommand,
+		Opts:    accessAuditOpts(ctx.Config),
+	}, "audit", "access")
+
+	root.AddChainAnyOrder(&Command{
+		Desc:    "List webhooks of repository",
+		Handler: ctx.ListHookCommand,
+		Opts:    listHookOpts(ctx.Config),
+	}, "list", "hook")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Create webhook, type: gitea, gogs, slack, rocketchat, msteams, discord or generic",
+		Handler: ctx.CreateHookCommand,
+		Opts:    createHookOpts(ctx.Config),
+	}, "create", "hook")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Change webhook",
+		Handler: ctx.EditHookCommand,
+		Opts:    editHookOpts(ctx.Config),
+	}, "edit", "hook")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Delete webhook",
+		Handler: ctx.DeleteHookCommand,
+		Opts:    deleteHookOpts(ctx.Config),
+	}, "delete", "hook")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Trigger test delivery of webhook",
+		Handler: ctx.TestHookCommand,
+		Opts:    hookSelectorOpts(ctx.Config),
+	}, "test", "hook")
+	root.AddChainAnyOrder(&Command{
+		Desc:    "Make webhooks of repositories match yaml spec",
+		Handler: ctx.SyncHookCommand,
+		Opts:    syncHookOpts(ctx.Config),
+	}, "sync", "hook")
+
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Receive gitea webhooks and post them to notify targets",
+		Handler: ctx.ServeHooksCommand,
+		Opts:    serveHooksOpts(ctx.Config),
+	}, "serve", "hooks")
+
+	root.AddChainStrictOrder(&Command{
+		Desc:    "List unread notifications, -a to include read ones",
+		Handler: ctx.ListInboxCommand,
+		Opts:    listInboxOpts(ctx.Config),
+	}, "inbox")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Mark notifications as read by id, filter or all of them",
+		Handler: ctx.ReadInboxCommand,
+		Opts:    readInboxOpts(ctx.Config),
+	}, "inbox", "read")
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Open url of notification in browser and mark it read",
+		Handler: ctx.OpenInboxCommand,
+		Opts:    openInboxOpts(ctx.Config),
+	}, "inbox", "open")
+
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Poll notifications and prs and report what changed",
+		Handler: ctx.WatchCommand,
+		Opts:    watchOpts(ctx.Config),
+	}, "watch")
+
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Run command after '--' for many repositories concurrently",
+		Handler: ctx.ForeachCommand,
+		Opts:    foreachOpts(ctx.Config),
+	}, "foreach")
+
+	root.AddChainStrictOrder(&Command{
+		Desc:    "Print changelog of prs merged between two refs",
+		Handler: ctx.ChangelogCommand,
+		Opts:    changelogOpts(ctx.Config),
+	}, "changelog")
 
 	ctx.CommandRoot = root
 
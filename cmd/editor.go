@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+func getEditor() string {
+	if e := os.Getenv("VISUAL"); e != "" {
+		return e
+	}
+	if e := os.Getenv("EDITOR"); e != "" {
+		return e
+	}
+	return "vi"
+}
+
+// hint is written below this line and everything from it on is dropped,
+// other lines starting with '#' are markdown headings and are kept
+const editScissors = "# ------------------------ >8 ------------------------"
+
+/*
+open initial text in users editor and return what was saved.
+hint, when given, is shown below scissors line like in git commit -v.
+*/
+func editText(initial, hint string) (string, error) {
+	fp, err := ioutil.TempFile("", "gitea-cli-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(fp.Name())
+
+	if hint != "" {
+		initial += "\n\n" + editScissors + "\n# Everything below the line above is ignored.\n#\n"
+		initial += "# " + strings.ReplaceAll(hint, "\n", "\n# ") + "\n"
+	}
+
+	if _, err := fp.WriteString(initial); err != nil {
+		fp.Close()
+		return "", err
+	}
+	if err := fp.Close(); err != nil {
+		return "", err
+	}
+
+	// editor may come with arguments eg. "code --wait"
+	c := exec.Command("sh", "-c", getEditor()+` "$1"`, "sh", fp.Name())
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("editor failed: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(fp.Name())
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(string(b), "\n")
+	for i := range lines {
+		if strings.TrimRight(lines[i], " \t\r") == editScissors {
+			lines = lines[:i]
+			break
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
+
+// options for message body, used together with getBody
+func bodyOpts() []CmdOpt {
+	return []CmdOpt{
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"m", "message"},
+				Label:    "message text",
+				Optional: true,
+				NoPrompt: true,
+			},
+		}, {
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"F", "file"},
+				Label:    "read message from file, '-' for stdin",
+				Optional: true,
+				NoPrompt: true,
+			},
+		}, {
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"e", "edit"},
+				Label:    "write message in $EDITOR [default: when no message nor file given]",
+				NoPrompt: true,
+				IsBool:   true,
+			},
+		},
+	}
+}
+
+/*
+get message from options returned by bodyOpts.
+editor is opened when asked for, or when message is required and none was given.
+hint is shown below scissors line in the editor.
+*/
+func getBody(opts []CmdOpt, required bool, hint string) (string, error) {
+	text := opts[0].Val.Str
+	file := opts[1].Val.Str
+	edit := opts[2].Val.Bool
+
+	var err error
+
+	switch {
+	case file == "-":
+		var b []byte
+		b, err = ioutil.ReadAll(os.Stdin)
+		text = string(b)
+	case file != "":
+		var b []byte
+		b, err = ioutil.ReadFile(file)
+		text = string(b)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if edit || (required && text == "") {
+		text, err = editText(text, hint)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	text = strings.TrimSpace(text)
+	if required && text == "" {
+		return "", fmt.Errorf("empty message, aborting")
+	}
+
+	return text, nil
+}
@@ -0,0 +1,391 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type foreachResult struct {
+	Repo     string
+	Output   []byte
+	Err      error
+	Duration time.Duration
+}
+
+// owner/repo per line, empty lines and '#' comments are skipped
+func readRepoList(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		fp, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer fp.Close()
+		r = fp
+	}
+	var res []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		l := strings.TrimSpace(sc.Text())
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		if strings.Count(l, "/") != 1 {
+			return nil, fmt.Errorf("%s: invalid repository '%s', expected owner/repo", path, l)
+		}
+		res = append(res, l)
+	}
+	return res, sc.Err()
+}
+
+// repositories from all sources given in opts, without duplicates
+func foreachRepos(gctx *gitea.Ctx, file, globs, org, query string, archived bool) ([]string, error) {
+	var (
+		names []string
+		repos []gitea.Repository
+	)
+
+	if file != "" {
+		n, err := readRepoList(file)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, n...)
+	}
+
+	if globs != "" {
+		r, err := expandRepoGlobs(gctx, splitList(globs), nil)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, r...)
+	}
+
+	if org != "" {
+		r, err := gctx.ListAllOwnerRepos(org)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, r...)
+	}
+
+	if query != "" {
+		r, err := gctx.SearchAllRepos(&gitea.SearchReposRequest{
+			Query: query,
+		})
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, r...)
+	}
+
+	for i := range repos {
+		if repos[i].Archived && !archived {
+			continue
+		}
+		names = append(names, repos[i].FullName)
+	}
+
+	seen := make(map[string]bool)
+	res := make([]string, 0, len(names))
+	for _, n := range names {
+		if !seen[n] {
+			seen[n] = true
+			res = append(res, n)
+		}
+	}
+	return res, nil
+}
+
+/*
+arguments of subcommand for repository.
+{owner}, {repo} and {fullname} are replaced,
+when none of them is used -o and -r are appended
+*/
+func foreachArgs(args []string, fullName string) []string {
+	parts := strings.SplitN(fullName, "/", 2)
+	rep := strings.NewReplacer("{owner}", parts[0], "{repo}", parts[1], "{fullname}", fullName)
+	res := make([]string, 0, len(args)+4)
+	templated := false
+	for _, a := range args {
+		b := rep.Replace(a)
+		if b != a {
+			templated = true
+		}
+		res = append(res, b)
+	}
+	if !templated {
+		res = append(res, "-o", parts[0], "-r", parts[1])
+	}
+	return res
+}
+
+// repositories already processed according to progress file
+func readProgress(path string) (map[string]bool, error) {
+	done := make(map[string]bool)
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range strings.Split(string(b), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			done[l] = true
+		}
+	}
+	return done, nil
+}
+
+func foreachOpts(c *common.Config) []CmdOpt {
+	opts := make([]CmdOpt, 0, 9)
+
+	// 0
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"f", "file"},
+			Label:    "file with owner/repo per line, - for stdin",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 1
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"repos"},
+			Label:    "comma separated owner/repo globs, eg. myorg/*",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 2
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"org"},
+			Label:    "every repository of organization or user",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 3
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"q", "query"},
+			Label:    "repositories matching search query",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"archived"},
+			Label:    "include archived repositories from org, query and globs [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	// 5
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"j", "jobs"},
+			Label:    "number of repositories processed concurrently [default: 4]",
+			NoPrompt: true,
+			DefaultStrFunc: func() (string, error) {
+				return "4", nil
+			},
+		},
+	})
+
+	// 6
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"d", "dry"},
+			Label:    "only print commands which would be run [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	// 7
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"progress"},
+			Label:    "file recording finished repositories, they are skipped when command is run again",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	return opts
+}
+
+/*
+run cli subcommand given after '--' for many repositories, eg.
+
+	foreach --org myorg -- new label -n bug -c ee0701
+
+subcommands run as separate processes without stdin, so they must not prompt
+*/
+func (ctx *CmdCtx) ForeachCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := foreachOpts(ctx.Config)
+	if err := GetOpts(commandArgs(os.Args[1:]), opts); err != nil {
+		return err
+	}
+
+	file := opts[0].Val.Str
+	globs := opts[1].Val.Str
+	org := opts[2].Val.Str
+	query := opts[3].Val.Str
+	dry := opts[6].Val.Bool
+	progress := opts[7].Val.Str
+
+	jobs, err := strconv.Atoi(opts[5].Val.Str)
+	if err != nil || jobs < 1 {
+		return fmt.Errorf("invalid number of jobs: %s", opts[5].Val.Str)
+	}
+
+	sub := passthroughArgs(os.Args[1:])
+	if len(sub) == 0 {
+		return fmt.Errorf("no subcommand given, usage: foreach [opts] -- <command> [command opts]")
+	}
+	if ctx.CommandRoot.FindInChain(FilterArgs(sub)) == nil {
+		return fmt.Errorf("unknown subcommand: %s", strings.Join(sub, " "))
+	}
+
+	if file == "" && globs == "" && org == "" && query == "" {
+		return fmt.Errorf("no repositories given, use one of --file, --repos, --org or --query")
+	}
+
+	gctx := ctx.newGiteaCtx()
+	if err := gctx.Validate(); err != nil {
+		return err
+	}
+
+	repos, err := foreachRepos(gctx, file, globs, org, query, opts[4].Val.Bool)
+	if err != nil {
+		return err
+	}
+
+	var progressFp *os.File
+	if progress != "" {
+		done, err := readProgress(progress)
+		if err != nil {
+			return err
+		}
+		todo := repos[:0]
+		for _, r := range repos {
+			if !done[r] {
+				todo = append(todo, r)
+			}
+		}
+		if skipped := len(repos) - len(todo); skipped > 0 {
+			fmt.Printf("skipping %d repositories already done according to %s\n", skipped, progress)
+		}
+		repos = todo
+		if !dry {
+			if progressFp, err = os.OpenFile(progress, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+				return err
+			}
+			defer progressFp.Close()
+		}
+	}
+
+	if dry {
+		for _, r := range repos {
+			fmt.Printf("%s: %s %s\n", r, os.Args[0], strings.Join(foreachArgs(sub, r), " "))
+		}
+		return nil
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	in := make(chan string)
+	out := make(chan foreachResult)
+	var wg sync.WaitGroup
+
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range in {
+				var buf bytes.Buffer
+				c := exec.Command(self, foreachArgs(sub, r)...)
+				c.Stdout = &buf
+				c.Stderr = &buf
+				start := time.Now()
+				err := c.Run()
+				out <- foreachResult{
+					Repo:     r,
+					Output:   buf.Bytes(),
+					Err:      err,
+					Duration: time.Since(start),
+				}
+			}
+		}()
+	}
+
+	go func() {
+		for _, r := range repos {
+			in <- r
+		}
+		close(in)
+		wg.Wait()
+		close(out)
+	}()
+
+	var failed []string
+	n := 0
+	for res := range out {
+		n++
+		status := "ok"
+		if res.Err != nil {
+			status = "failed: " + res.Err.Error()
+			failed = append(failed, res.Repo)
+		} else if progressFp != nil {
+			if _, err := fmt.Fprintln(progressFp, res.Repo); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("[%d/%d] %s %s (%s)\n", n, len(repos), res.Repo, status, res.Duration.Round(time.Millisecond))
+		for _, l := range strings.Split(strings.TrimRight(string(res.Output), "\n"), "\n") {
+			if l != "" {
+				fmt.Printf("\t%s\n", l)
+			}
+		}
+	}
+
+	fmt.Printf("succeeded: %d, failed: %d\n", len(repos)-len(failed), len(failed))
+	if len(failed) > 0 {
+		for _, r := range failed {
+			fmt.Printf("\t%s\n", r)
+		}
+		return fmt.Errorf("subcommand failed for %d repositories", len(failed))
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// run git and return its trimmed stdout
+func gitOutput(args ...string) (string, error) {
+	o, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(o)), nil
+}
+
+// run git with output attached to the terminal
+func gitRun(args ...string) error {
+	c := exec.Command("git", args...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("git %s: %v", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// remote tracked by branch, origin if there is none
+func getBranchRemote(branch string) string {
+	r, err := gitOutput("config", "branch."+branch+".remote")
+	if err != nil || r == "" {
+		return "origin"
+	}
+	return r
+}
+
+// sha of the branch on remote, empty if branch doesnt exist there
+func getRemoteBranchSha(remote, branch string) (string, error) {
+	o, err := gitOutput("ls-remote", "--heads", remote, "refs/heads/"+branch)
+	if err != nil {
+		return "", fmt.Errorf("couldnt query remote %s: %v", remote, err)
+	}
+	f := strings.Fields(o)
+	if len(f) == 0 {
+		return "", nil
+	}
+	return f[0], nil
+}
+
+// sha of the local branch, empty if branch doesnt exist locally
+func getLocalBranchSha(branch string) string {
+	o, err := gitOutput("rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	if err != nil {
+		return ""
+	}
+	return o
+}
+
+/*
+make sure that local branch is present on remote and up to date.
+if it isnt then push it when push is set, otherwise ask user.
+branches which dont exist locally are left alone.
+*/
+func ensureBranchPushed(branch string, push, dry bool) error {
+	local := getLocalBranchSha(branch)
+	if local == "" {
+		return nil
+	}
+
+	remote := getBranchRemote(branch)
+	remoteSha, err := getRemoteBranchSha(remote, branch)
+	if err != nil {
+		return err
+	}
+
+	if remoteSha == local {
+		return nil
+	}
+
+	if remoteSha == "" {
+		fmt.Printf("branch %s doesnt exist on %s\n", branch, remote)
+	} else {
+		ahead, aerr := gitOutput("rev-list", "--count", remoteSha+".."+local)
+		behind, berr := gitOutput("rev-list", "--count", local+".."+remoteSha)
+		switch {
+		case aerr != nil || berr != nil:
+			// remote commit isnt fetched, push would be rejected anyway
+			fmt.Printf("warning: %s differs from %s/%s, fetch it to compare\n", branch, remote, branch)
+			return nil
+		case ahead == "0":
+			// only behind, remote already has everything
+			return nil
+		case behind != "0":
+			if dry {
+				fmt.Printf("warning: %s has diverged from %s/%s\n", branch, remote, branch)
+				return nil
+			}
+			return fmt.Errorf("%s has diverged from %s/%s (%s local, %s remote commit(s)), rebase or push it yourself",
+				branch, remote, branch, ahead, behind)
+		}
+		fmt.Printf("warning: %s commit(s) on %s are not pushed to %s\n", ahead, branch, remote)
+	}
+
+	if dry {
+		return nil
+	}
+
+	if !push {
+		push = confirm(fmt.Sprintf("push %s to %s?", branch, remote))
+	}
+
+	if !push {
+		if remoteSha == "" {
+			return fmt.Errorf("head branch %s doesnt exist on %s", branch, remote)
+		}
+		return nil
+	}
+
+	return gitRun("push", "-u", remote, branch)
+}
+
+// all local branch names
+func getLocalBranches() ([]string, error) {
+	o, err := gitOutput("for-each-ref", "--format=%(refname:short)", "refs/heads/")
+	if err != nil {
+		return nil, err
+	}
+	if o == "" {
+		return nil, nil
+	}
+	return strings.Split(o, "\n"), nil
+}
+
+/*
+delete local branch together with its remote tracking ref.
+if branch is checked out then switch to base and pull it first.
+mergedSha is the commit which made it to the server,
+if local branch points somewhere else user is asked before deleting.
+*/
+func cleanupLocalBranch(branch, base, mergedSha string) error {
+	local := getLocalBranchSha(branch)
+	if local == "" {
+		return nil
+	}
+
+	if mergedSha != "" && local != mergedSha {
+		if !confirm(fmt.Sprintf("local %s differs from what was merged, delete it anyway?", branch)) {
+			return nil
+		}
+	}
+
+	remote := getBranchRemote(branch)
+
+	if getBranch() == branch {
+		if err := gitRun("checkout", base); err != nil {
+			return err
+		}
+		if err := gitRun("pull", "--ff-only"); err != nil {
+			return err
+		}
+	}
+
+	if err := gitRun("branch", "-D", branch); err != nil {
+		return err
+	}
+
+	// tracking ref may be already gone, ignore errors
+	gitOutput("branch", "-dr", remote+"/"+branch)
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"crypto/rand"
 	"fmt"
+	"io"
 	"math/big"
 	"os"
 	"strings"
@@ -52,6 +53,26 @@ type CmdOpt struct {
 	Val  CmdOptVal
 }
 
+// arguments before '--', the rest is passed through by commands like foreach
+func commandArgs(allArgs []string) []string {
+	for i := range allArgs {
+		if allArgs[i] == "--" {
+			return allArgs[:i]
+		}
+	}
+	return allArgs
+}
+
+// arguments after '--', nil if there is none
+func passthroughArgs(allArgs []string) []string {
+	for i := range allArgs {
+		if allArgs[i] == "--" {
+			return allArgs[i+1:]
+		}
+	}
+	return nil
+}
+
 func FilterArgs(allArgs []string) []string {
 	ret := make([]string, 3)
 
@@ -166,7 +187,13 @@ func GetOpts(args []string, reqOpts []CmdOpt) error {
 					}
 					// standard read input
 				} else {
-					tmp, _ = reader.ReadString('\n')
+					var err error
+					tmp, err = reader.ReadString('\n')
+					// stdin closed, eg. when run from foreach - dont prompt forever
+					if err == io.EOF && tmp == "" && !spec.Optional {
+						fmt.Println()
+						return fmt.Errorf("no value given for required option --%s", spec.ArgFlags[len(spec.ArgFlags)-1])
+					}
 				}
 			}
 
@@ -194,6 +221,35 @@ func GetOpts(args []string, reqOpts []CmdOpt) error {
 	return nil
 }
 
+// split comma separated list, empty elements are dropped
+func splitList(s string) []string {
+	var res []string
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// ask y/n question until user answers, EOF is treated as no
+func confirm(msg string) bool {
+	var tmp string
+	for {
+		fmt.Printf("%s [y/n]: ", msg)
+		if _, err := fmt.Scanln(&tmp); err == io.EOF {
+			fmt.Println()
+			return false
+		}
+		switch tmp {
+		case "y":
+			return true
+		case "n":
+			return false
+		}
+	}
+}
+
 // dbg
 // func (c CommandHandler) MarshalJSON() ([]byte, error) {
 // 	if c == nil {
@@ -0,0 +1,713 @@
+package cmd
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// types not known by gitea itself mapped to ones which work for them
+var hookTypeAliases = map[string]string{
+	"rocketchat": "slack",
+	"mattermost": "slack",
+	"generic":    "gitea",
+}
+
+// hook as declared in hooks file or on command line
+type hookDef struct {
+	Type string `yaml:"type"`
+	// url and secret may reference environment variables, eg. ${HOOK_SECRET}
+	Url          string   `yaml:"url"`
+	Secret       string   `yaml:"secret"`
+	ContentType  string   `yaml:"content_type"`
+	Events       []string `yaml:"events"`
+	BranchFilter string   `yaml:"branch_filter"`
+	// slack and compatible only
+	Channel  string `yaml:"channel"`
+	Username string `yaml:"username"`
+	Active   *bool  `yaml:"active"`
+}
+
+// file passed to hook sync
+type hookSpec struct {
+	Hooks []hookDef `yaml:"hooks"`
+	// remove hooks which arent listed
+	DeleteUnlisted bool `yaml:"delete_unlisted"`
+}
+
+type hookChange struct {
+	Action planAction
+	Hook   hookDef
+	// id of existing hook
+	ID   int
+	Diff []string
+}
+
+// fill defaults and resolve aliases and environment variables
+func (h *hookDef) normalize() error {
+	h.Type = strings.ToLower(h.Type)
+	if h.Type == "" {
+		h.Type = "gitea"
+	}
+	if t, e := hookTypeAliases[h.Type]; e {
+		h.Type = t
+	}
+	h.Url = os.ExpandEnv(h.Url)
+	h.Secret = os.ExpandEnv(h.Secret)
+	if h.Url == "" {
+		return fmt.Errorf("%s hook has no url", h.Type)
+	}
+	if h.Type == "slack" && h.Channel == "" {
+		return fmt.Errorf("slack hook %s has no channel", h.Url)
+	}
+	if h.ContentType == "" {
+		h.ContentType = "json"
+	}
+	if len(h.Events) == 0 {
+		h.Events = []string{"push"}
+	}
+	if h.BranchFilter == "" {
+		h.BranchFilter = "*"
+	}
+	if h.Active == nil {
+		active := true
+		h.Active = &active
+	}
+	return nil
+}
+
+func (h *hookDef) config() map[string]string {
+	c := map[string]string{
+		"url":          h.Url,
+		"content_type": h.ContentType,
+	}
+	if h.Type == "gitea" || h.Type == "gogs" {
+		c["http_method"] = "post"
+	}
+	if h.Secret != "" {
+		c["secret"] = h.Secret
+	}
+	if h.Channel != "" {
+		c["channel"] = h.Channel
+	}
+	if h.Username != "" {
+		c["username"] = h.Username
+	}
+	return c
+}
+
+// events gitea turns grouping events into, it lists hooks with these
+var hookEventGroups = map[string][]string{
+	"issues": {"issues", "issue_assign", "issue_label", "issue_milestone", "issue_comment"},
+	"pull_request": {
+		"pull_request", "pull_request_assign", "pull_request_label", "pull_request_milestone",
+		"pull_request_comment", "pull_request_review_approved", "pull_request_review_rejected",
+		"pull_request_review_comment", "pull_request_sync", "pull_request_review_request",
+	},
+	"pull_request_review": {"pull_request_review_approved", "pull_request_review_rejected", "pull_request_review_comment"},
+}
+
+// sorted events as gitea stores them
+func expandHookEvents(events []string) []string {
+	seen := make(map[string]bool)
+	var res []string
+	for _, e := range events {
+		sub, ok := hookEventGroups[e]
+		if !ok {
+			sub = []string{e}
+		}
+		for _, s := range sub {
+			if !seen[s] {
+				seen[s] = true
+				res = append(res, s)
+			}
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// differences between live hook and wanted one, secret cant be compared as gitea doesnt return it
+func diffHook(live *gitea.Hook, want *hookDef) []string {
+	var diff []string
+	for _, k := range []string{"content_type", "channel", "username"} {
+		w := want.config()[k]
+		if k != "content_type" || live.Type == "gitea" || live.Type == "gogs" {
+			if live.Config[k] != w {
+				diff = append(diff, fmt.Sprintf("%s: %q -> %q", k, live.Config[k], w))
+			}
+		}
+	}
+	le, we := expandHookEvents(live.Events), expandHookEvents(want.Events)
+	if strings.Join(le, ",") != strings.Join(we, ",") {
+		diff = append(diff, fmt.Sprintf("events: %s -> %s", strings.Join(le, ","), strings.Join(we, ",")))
+	}
+	if live.BranchFilter != want.BranchFilter && !(live.BranchFilter == "" && want.BranchFilter == "*") {
+		diff = append(diff, fmt.Sprintf("branch_filter: %q -> %q", live.BranchFilter, want.BranchFilter))
+	}
+	if live.Active != *want.Active {
+		diff = append(diff, fmt.Sprintf("active: %t -> %t", live.Active, *want.Active))
+	}
+	return diff
+}
+
+func readHookSpec(path string) (*hookSpec, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec := new(hookSpec)
+	if err := yaml.Unmarshal(b, spec); err != nil {
+		return nil, err
+	}
+	for i := range spec.Hooks {
+		if err := spec.Hooks[i].normalize(); err != nil {
+			return nil, fmt.Errorf("%s: hook %d: %v", path, i, err)
+		}
+	}
+	return spec, nil
+}
+
+func planHooks(repoCtx *gitea.RepoCtx, spec *hookSpec) ([]hookChange, error) {
+	live, err := repoCtx.ListAllHooks()
+	if err != nil {
+		return nil, err
+	}
+
+	// hooks are identified by type and url
+	key := func(typ, url string) string {
+		return typ + " " + url
+	}
+	byKey := make(map[string]*gitea.Hook)
+	for i := range live {
+		byKey[key(live[i].Type, live[i].Config["url"])] = &live[i]
+	}
+
+	var changes []hookChange
+	wanted := make(map[string]bool)
+
+	for _, want := range spec.Hooks {
+		k := key(want.Type, want.Url)
+		wanted[k] = true
+		h, e := byKey[k]
+		if !e {
+			changes = append(changes, hookChange{
+				Action: planCreate,
+				Hook:   want,
+			})
+			continue
+		}
+		if diff := diffHook(h, &want); len(diff) > 0 {
+			changes = append(changes, hookChange{
+				Action: planUpdate,
+				Hook:   want,
+				ID:     h.ID,
+				Diff:   diff,
+			})
+		}
+	}
+
+	if spec.DeleteUnlisted {
+		for i := range live {
+			if !wanted[key(live[i].Type, live[i].Config["url"])] {
+				changes = append(changes, hookChange{
+					Action: planDelete,
+					Hook:   hookDef{Type: live[i].Type, Url: live[i].Config["url"]},
+					ID:     live[i].ID,
+				})
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+func applyHooks(repoCtx *gitea.RepoCtx, changes []hookChange) error {
+	for i := range changes {
+		c := &changes[i]
+		var err error
+		switch c.Action {
+		case planCreate:
+			_, err = repoCtx.CreateHook(&gitea.CreateHookRequest{
+				Opt: gitea.CreateHookOption{
+					Type:         c.Hook.Type,
+					Config:       c.Hook.config(),
+					Events:       c.Hook.Events,
+					BranchFilter: c.Hook.BranchFilter,
+					Active:       *c.Hook.Active,
+				},
+			})
+		case planUpdate:
+			_, err = repoCtx.EditHook(&gitea.EditHookRequest{
+				ID: c.ID,
+				Opt: gitea.EditHookOption{
+					Config:       c.Hook.config(),
+					Events:       c.Hook.Events,
+					BranchFilter: &c.Hook.BranchFilter,
+					Active:       c.Hook.Active,
+				},
+			})
+		case planDelete:
+			err = repoCtx.DeleteHook(&gitea.HookRequest{
+				ID: c.ID,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("%s/%s: %s hook %s: %v", repoCtx.Owner, repoCtx.Repo, c.Hook.Type, c.Hook.Url, err)
+		}
+	}
+	return nil
+}
+
+func printHookLine(h *gitea.Hook) {
+	fmt.Printf("hook: id=%d, type=%s, url=%s, events=%s, branches=%s, active=%t\n",
+		h.ID, h.Type, h.Config["url"], strings.Join(h.Events, ","), h.BranchFilter, h.Active)
+}
+
+func findHook(repoCtx *gitea.RepoCtx, id string) (*gitea.Hook, error) {
+	i, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hook id: %s", id)
+	}
+	hooks, err := repoCtx.ListAllHooks()
+	if err != nil {
+		return nil, err
+	}
+	for j := range hooks {
+		if hooks[j].ID == i {
+			return &hooks[j], nil
+		}
+	}
+	return nil, fmt.Errorf("hook %d not found in %s/%s", i, repoCtx.Owner, repoCtx.Repo)
+}
+
+func hookSelectorOpts(c *common.Config) []CmdOpt {
+	return append(repoInfoOpts(c), addOptWithDefaultVal("hook id    ", "", []string{"i", "id"}, ""))
+}
+
+func listHookOpts(c *common.Config) []CmdOpt {
+	return append(repoInfoOpts(c), formatOpt())
+}
+
+func (ctx *CmdCtx) ListHookCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := listHookOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	format := opts[2].Val.Str
+	if err := checkFormat(format, formatText, formatJson); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	hooks, err := repoCtx.ListAllHooks()
+	if err != nil {
+		return err
+	}
+
+	if format == formatJson {
+		return printJson(hooks)
+	}
+
+	for i := range hooks {
+		printHookLine(&hooks[i])
+	}
+
+	return nil
+}
+
+// options shared by hook create and edit, starting at index 3
+func hookSettingOpts() []CmdOpt {
+	return []CmdOpt{
+		// +0
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"u", "url"},
+				Label:    "target url",
+				Optional: true,
+				NoPrompt: true,
+			},
+		},
+		// +1
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"e", "events"},
+				Label:    "comma separated events, eg. push,pull_request,issues,release [default: push]",
+				Optional: true,
+				NoPrompt: true,
+			},
+		},
+		// +2
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"s", "secret"},
+				Label:    "secret used to sign payloads",
+				Optional: true,
+				NoPrompt: true,
+			},
+		},
+		// +3
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"b", "branches"},
+				Label:    "branch filter glob [default: *]",
+				Optional: true,
+				NoPrompt: true,
+			},
+		},
+		// +4
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"channel"},
+				Label:    "channel for slack and rocketchat hooks",
+				Optional: true,
+				NoPrompt: true,
+			},
+		},
+	}
+}
+
+func createHookOpts(c *common.Config) []CmdOpt {
+	// 0-1
+	opts := repoInfoOpts(c)
+
+	// 2
+	opts = append(opts, addOptWithDefaultVal("hook type  ", "", []string{"t", "type"}, "gitea"))
+
+	// 3-7
+	opts = append(opts, hookSettingOpts()...)
+	opts[3].Spec.Optional = false
+	opts[3].Spec.NoPrompt = false
+
+	// 8
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"contenttype"},
+			Label:    "json or form, for gitea hooks [default: json]",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 9
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"inactive"},
+			Label:    "create hook disabled [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) CreateHookCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := createHookOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	active := !opts[9].Val.Bool
+	def := hookDef{
+		Type:         opts[2].Val.Str,
+		Url:          opts[3].Val.Str,
+		Events:       splitList(opts[4].Val.Str),
+		Secret:       opts[5].Val.Str,
+		BranchFilter: opts[6].Val.Str,
+		Channel:      opts[7].Val.Str,
+		ContentType:  opts[8].Val.Str,
+		Active:       &active,
+	}
+	if err := def.normalize(); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	h, err := repoCtx.CreateHook(&gitea.CreateHookRequest{
+		Opt: gitea.CreateHookOption{
+			Type:         def.Type,
+			Config:       def.config(),
+			Events:       def.Events,
+			BranchFilter: def.BranchFilter,
+			Active:       *def.Active,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	printHookLine(h)
+
+	return nil
+}
+
+func editHookOpts(c *common.Config) []CmdOpt {
+	// 0-2
+	opts := hookSelectorOpts(c)
+
+	// 3-7
+	opts = append(opts, hookSettingOpts()...)
+
+	// 8
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"active"},
+			Label:    "true or false to enable or disable hook",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) EditHookCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := editHookOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	active, err := parseTriState("active", opts[8].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	h, err := findHook(repoCtx, opts[2].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	// config is sent whole, start from current one
+	config := h.Config
+	for k, v := range map[string]string{
+		"url":     opts[3].Val.Str,
+		"secret":  os.ExpandEnv(opts[5].Val.Str),
+		"channel": opts[7].Val.Str,
+	} {
+		if v != "" {
+			config[k] = v
+		}
+	}
+
+	req := gitea.EditHookRequest{
+		ID: h.ID,
+		Opt: gitea.EditHookOption{
+			Config: config,
+			Events: splitList(opts[4].Val.Str),
+			Active: active,
+		},
+	}
+	if b := opts[6].Val.Str; b != "" {
+		req.Opt.BranchFilter = &b
+	}
+
+	if h, err = repoCtx.EditHook(&req); err != nil {
+		return err
+	}
+
+	printHookLine(h)
+
+	return nil
+}
+
+func deleteHookOpts(c *common.Config) []CmdOpt {
+	return append(hookSelectorOpts(c), CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"y", "yes"},
+			Label:    "dont ask for confirmation [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+}
+
+func (ctx *CmdCtx) DeleteHookCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := deleteHookOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	h, err := findHook(repoCtx, opts[2].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	if !opts[3].Val.Bool && !confirm(fmt.Sprintf("delete %s hook %s from %s/%s?", h.Type, h.Config["url"], repoCtx.Owner, repoCtx.Repo)) {
+		return nil
+	}
+
+	return repoCtx.DeleteHook(&gitea.HookRequest{
+		ID: h.ID,
+	})
+}
+
+func (ctx *CmdCtx) TestHookCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := hookSelectorOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	id, err := strconv.Atoi(opts[2].Val.Str)
+	if err != nil {
+		return fmt.Errorf("invalid hook id: %s", opts[2].Val.Str)
+	}
+
+	if err := repoCtx.TestHook(&gitea.HookRequest{
+		ID: id,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("test delivery of hook %d triggered, check its recent deliveries in repository settings\n", id)
+
+	return nil
+}
+
+func syncHookOpts(c *common.Config) []CmdOpt {
+	return []CmdOpt{
+		reposOpt(c),
+		addOptWithDefaultVal("hooks file ", "yaml with declared hooks", []string{"f", "file"}, ""),
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"apply"},
+				Label:    "make the changes, otherwise only plan is printed [default: false]",
+				NoPrompt: true,
+				IsBool:   true,
+			},
+		},
+	}
+}
+
+func (ctx *CmdCtx) SyncHookCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := syncHookOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	apply := opts[2].Val.Bool
+
+	spec, err := readHookSpec(opts[1].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	gctx := ctx.newGiteaCtx()
+	if err := gctx.Validate(); err != nil {
+		return err
+	}
+
+	repos, err := expandRepoGlobs(gctx, splitList(opts[0].Val.Str), nil)
+	if err != nil {
+		return err
+	}
+
+	plans := make([][]hookChange, len(repos))
+	total := 0
+	for i := range repos {
+		r := &repos[i]
+		fmt.Printf("%s:\n", r.FullName)
+		if r.Archived {
+			fmt.Println("    archived, skipping")
+			continue
+		}
+
+		repoCtx := gctx.RepoCtx(r.Owner.Login, r.Name)
+		changes, err := planHooks(repoCtx, spec)
+		if err != nil {
+			return fmt.Errorf("%s: %v", r.FullName, err)
+		}
+
+		if len(changes) == 0 {
+			fmt.Println("    up to date")
+			continue
+		}
+		for j := range changes {
+			fmt.Printf("    %s %s hook %s\n", changes[j].Action, changes[j].Hook.Type, changes[j].Hook.Url)
+			for k := range changes[j].Diff {
+				fmt.Printf("        %s\n", changes[j].Diff[k])
+			}
+		}
+		plans[i] = changes
+		total += len(changes)
+	}
+
+	fmt.Printf("\n%d change(s) in %d repositories\n", total, len(repos))
+
+	if total == 0 {
+		return nil
+	}
+
+	if !apply {
+		fmt.Println("run with --apply to make these changes")
+		return nil
+	}
+
+	for i := range repos {
+		if len(plans[i]) == 0 {
+			continue
+		}
+		r := &repos[i]
+		if err := applyHooks(gctx.RepoCtx(r.Owner.Login, r.Name), plans[i]); err != nil {
+			return err
+		}
+		fmt.Printf("%s: applied %d change(s)\n", r.FullName, len(plans[i]))
+	}
+
+	return nil
+}
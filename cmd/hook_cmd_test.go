@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"gitea-cli/gitea"
+	"testing"
+)
+
+func TestDiffHookEvents(t *testing.T) {
+	want := hookDef{Url: "https://ci.example.com/hook", Events: []string{"push", "pull_request"}}
+	if err := want.normalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	// events of such hook as returned by gitea 1.21
+	live := gitea.Hook{
+		Type:   "gitea",
+		Config: map[string]string{"url": want.Url, "content_type": "json", "http_method": "post"},
+		Events: []string{
+			"push", "pull_request", "pull_request_assign", "pull_request_label", "pull_request_milestone",
+			"pull_request_comment", "pull_request_review_approved", "pull_request_review_rejected",
+			"pull_request_review_comment", "pull_request_sync", "pull_request_review_request",
+		},
+		BranchFilter: "*",
+		Active:       true,
+	}
+
+	if diff := diffHook(&live, &want); len(diff) != 0 {
+		t.Fatalf("expected no changes, got %q", diff)
+	}
+
+	live.Events = []string{"push"}
+	if diff := diffHook(&live, &want); len(diff) != 1 {
+		t.Fatalf("expected events change, got %q", diff)
+	}
+}
@@ -0,0 +1,336 @@
+package cmd
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// subject types accepted by --type and their api names
+var inboxTypes = map[string]string{
+	"issue":      "Issue",
+	"pull":       "Pull",
+	"commit":     "Commit",
+	"repository": "Repository",
+}
+
+func inboxFilterOpts() []CmdOpt {
+	return []CmdOpt{
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"repos"},
+				Label:    "comma separated owner/repo globs",
+				Optional: true,
+				NoPrompt: true,
+			},
+		},
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"t", "type"},
+				Label:    "comma separated types: issue, pull, commit, repository (releases)",
+				Optional: true,
+				NoPrompt: true,
+			},
+		},
+	}
+}
+
+type inboxFilter struct {
+	globs []string
+	types []string
+}
+
+func readInboxFilter(repos, types string) (*inboxFilter, error) {
+	f := &inboxFilter{
+		globs: splitList(repos),
+	}
+	for _, t := range splitList(types) {
+		api, ok := inboxTypes[strings.ToLower(t)]
+		if !ok {
+			return nil, fmt.Errorf("invalid type %s, expected issue, pull, commit or repository", t)
+		}
+		f.types = append(f.types, api)
+	}
+	return f, nil
+}
+
+func (f *inboxFilter) empty() bool {
+	return len(f.globs) == 0 && len(f.types) == 0
+}
+
+func threadRepo(t *gitea.NotificationThread) string {
+	if t.Repository == nil {
+		return ""
+	}
+	return t.Repository.FullName
+}
+
+// types are filtered by gitea, repositories here
+func listInbox(gctx *gitea.Ctx, all bool, f *inboxFilter) ([]gitea.NotificationThread, error) {
+	threads, err := gctx.ListAllNotifications(&gitea.ListNotificationsRequest{
+		All:          all,
+		SubjectTypes: f.types,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(f.globs) == 0 {
+		return threads, nil
+	}
+	res := make([]gitea.NotificationThread, 0, len(threads))
+	for i := range threads {
+		if matchAnyGlob(f.globs, threadRepo(&threads[i])) {
+			res = append(res, threads[i])
+		}
+	}
+	return res, nil
+}
+
+func printThreadLine(t *gitea.NotificationThread) {
+	unread := " "
+	if t.Unread {
+		unread = "*"
+	}
+	title := t.Subject.Title
+	if t.Subject.State != "" {
+		title += " (" + t.Subject.State + ")"
+	}
+	fmt.Printf("%s %-6d %-10s %-30s %s\n", unread, t.ID, strings.ToLower(t.Subject.Type), threadRepo(t), title)
+}
+
+func listInboxOpts(c *common.Config) []CmdOpt {
+	return append(inboxFilterOpts(),
+		// 2
+		CmdOpt{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"a", "all"},
+				Label:    "include read notifications [default: false]",
+				NoPrompt: true,
+				IsBool:   true,
+			},
+		},
+		// 3
+		formatOpt(),
+	)
+}
+
+func (ctx *CmdCtx) ListInboxCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := listInboxOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	format := opts[3].Val.Str
+	if err := checkFormat(format, formatText, formatJson); err != nil {
+		return err
+	}
+
+	f, err := readInboxFilter(opts[0].Val.Str, opts[1].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	gctx := ctx.newGiteaCtx()
+	if err := gctx.Validate(); err != nil {
+		return err
+	}
+
+	threads, err := listInbox(gctx, opts[2].Val.Bool, f)
+	if err != nil {
+		return err
+	}
+
+	if format == formatJson {
+		return printJson(threads)
+	}
+
+	for i := range threads {
+		printThreadLine(&threads[i])
+	}
+
+	return nil
+}
+
+func parseThreadIds(s string) ([]int, error) {
+	var res []int
+	for _, e := range splitList(s) {
+		id, err := strconv.Atoi(e)
+		if err != nil {
+			return nil, fmt.Errorf("invalid notification id: %s", e)
+		}
+		res = append(res, id)
+	}
+	return res, nil
+}
+
+func readInboxOpts(c *common.Config) []CmdOpt {
+	return append(inboxFilterOpts(),
+		// 2
+		CmdOpt{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"i", "id"},
+				Label:    "comma separated notification ids",
+				Optional: true,
+				NoPrompt: true,
+			},
+		},
+		// 3
+		CmdOpt{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"a", "all"},
+				Label:    "mark every unread notification as read [default: false]",
+				NoPrompt: true,
+				IsBool:   true,
+			},
+		},
+	)
+}
+
+func (ctx *CmdCtx) ReadInboxCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := readInboxOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	f, err := readInboxFilter(opts[0].Val.Str, opts[1].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	ids, err := parseThreadIds(opts[2].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	all := opts[3].Val.Bool
+
+	gctx := ctx.newGiteaCtx()
+	if err := gctx.Validate(); err != nil {
+		return err
+	}
+
+	switch {
+	case len(ids) > 0:
+		if !f.empty() || all {
+			return fmt.Errorf("--id cant be combined with --repos, --type or --all")
+		}
+	case !f.empty():
+		threads, err := listInbox(gctx, false, f)
+		if err != nil {
+			return err
+		}
+		for i := range threads {
+			ids = append(ids, threads[i].ID)
+		}
+	case all:
+		if err := gctx.ReadAllNotifications(&gitea.ReadNotificationsRequest{}); err != nil {
+			return err
+		}
+		fmt.Println("marked all notifications as read")
+		return nil
+	default:
+		return fmt.Errorf("nothing to mark, give --id, --repos, --type or --all")
+	}
+
+	for _, id := range ids {
+		if err := gctx.ReadNotificationThread(&gitea.NotificationThreadRequest{
+			ID: id,
+		}); err != nil {
+			return fmt.Errorf("%d: %v", id, err)
+		}
+	}
+
+	fmt.Printf("marked %d notification(s) as read\n", len(ids))
+
+	return nil
+}
+
+// $BROWSER when set, xdg-open otherwise
+func openUrl(u string) error {
+	bin := os.Getenv("BROWSER")
+	if bin == "" {
+		bin = "xdg-open"
+	}
+	path, err := exec.LookPath(bin)
+	if err != nil {
+		return fmt.Errorf("cant open %s: %v", u, err)
+	}
+	return exec.Command(path, u).Start()
+}
+
+func openInboxOpts(c *common.Config) []CmdOpt {
+	return []CmdOpt{
+		// 0
+		addOptWithDefaultVal("notification id", "", []string{"i", "id"}, ""),
+		// 1
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"keep"},
+				Label:    "keep notification unread [default: false]",
+				NoPrompt: true,
+				IsBool:   true,
+			},
+		},
+	}
+}
+
+func (ctx *CmdCtx) OpenInboxCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := openInboxOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	id, err := strconv.Atoi(opts[0].Val.Str)
+	if err != nil {
+		return fmt.Errorf("invalid notification id: %s", opts[0].Val.Str)
+	}
+
+	gctx := ctx.newGiteaCtx()
+	if err := gctx.Validate(); err != nil {
+		return err
+	}
+
+	t, err := gctx.GetNotificationThread(&gitea.NotificationThreadRequest{
+		ID: id,
+	})
+	if err != nil {
+		return err
+	}
+
+	u := t.Subject.LatestCommentHtmlUrl
+	if u == "" {
+		u = t.Subject.HtmlUrl
+	}
+	if u == "" {
+		return fmt.Errorf("notification %d has no url", id)
+	}
+
+	fmt.Println(u)
+	if err := openUrl(u); err != nil {
+		return err
+	}
+
+	if opts[1].Val.Bool || !t.Unread {
+		return nil
+	}
+
+	return gctx.ReadNotificationThread(&gitea.NotificationThreadRequest{
+		ID: id,
+	})
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"fmt"
+	"gitea-cli/gitea"
+	"strconv"
+	"strings"
+)
+
+/*
+inline review works on a copy of the pr diff opened in the editor.
+comments are written on new lines starting with '>' directly below
+the diff line they refer to, consecutive '>' lines make one comment.
+text above the first file of the diff becomes the review body.
+diff lines never start with '>' so they can be told apart.
+*/
+
+const inlineCommentMarker = ">"
+
+const inlineReviewHint = `Review for PR #%d: %s
+
+Write the review summary above the diff.
+Comment a line by adding lines starting with '>' right below it, eg.
+  +	x := compute()
+  > this can be moved out of the loop`
+
+// text opened in the editor
+func inlineReviewTemplate(body, diff string) string {
+	return body + "\n\n" + strings.TrimRight(diff, "\n")
+}
+
+// parse "@@ -a,b +c,d @@" into start lines and line counts of the old and new file
+func parseHunkHeader(l string) (int, int, int, int, error) {
+	f := strings.Fields(l)
+	if len(f) < 3 || !strings.HasPrefix(f[1], "-") || !strings.HasPrefix(f[2], "+") {
+		return 0, 0, 0, 0, fmt.Errorf("invalid hunk header: %s", l)
+	}
+	old, oldCount, err := parseHunkRange(f[1][1:])
+	if err != nil {
+		return 0, 0, 0, 0, fmt.Errorf("invalid hunk header: %s", l)
+	}
+	new, newCount, err := parseHunkRange(f[2][1:])
+	if err != nil {
+		return 0, 0, 0, 0, fmt.Errorf("invalid hunk header: %s", l)
+	}
+	return old, oldCount, new, newCount, nil
+}
+
+// "start,count" or "start" which means count of 1
+func parseHunkRange(r string) (int, int, error) {
+	p := strings.SplitN(r, ",", 2)
+	start, err := strconv.Atoi(p[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(p) == 1 {
+		return start, 1, nil
+	}
+	count, err := strconv.Atoi(p[1])
+	return start, count, err
+}
+
+// extract review body and positioned comments from edited diff
+func parseInlineReview(text string) (string, []gitea.CreatePullReviewComment, error) {
+	var (
+		body     []string
+		comments []gitea.CreatePullReviewComment
+		inDiff   bool
+
+		path    string
+		oldLine int
+		newLine int
+		oldLeft int
+		newLeft int
+		inHunk  bool
+		// previous line was part of a comment
+		inComment bool
+		target    *gitea.CreatePullReviewComment
+		building  *gitea.CreatePullReviewComment
+	)
+
+	flush := func() {
+		if building != nil {
+			building.Body = strings.TrimSpace(building.Body)
+			if building.Body != "" {
+				comments = append(comments, *building)
+			}
+			building = nil
+		}
+	}
+
+	for n, l := range strings.Split(text, "\n") {
+		if strings.HasPrefix(l, inlineCommentMarker) {
+			if !inDiff {
+				body = append(body, l)
+				continue
+			}
+			if target == nil {
+				return "", nil, fmt.Errorf("line %d: comment is not below any diff line", n+1)
+			}
+			c := strings.TrimPrefix(strings.TrimPrefix(l, inlineCommentMarker), " ")
+			if building == nil {
+				building = &gitea.CreatePullReviewComment{
+					Path:        target.Path,
+					OldPosition: target.OldPosition,
+					NewPosition: target.NewPosition,
+				}
+			} else {
+				building.Body += "\n"
+			}
+			building.Body += c
+			inComment = true
+			continue
+		}
+
+		// blank line separating comments, not a context line
+		if l == "" && inComment {
+			continue
+		}
+		inComment = false
+
+		flush()
+
+		if strings.HasPrefix(l, "diff --git ") {
+			inDiff = true
+			inHunk = false
+			target = nil
+			path = ""
+			continue
+		}
+
+		if !inDiff {
+			body = append(body, l)
+			continue
+		}
+
+		switch {
+		case !inHunk && strings.HasPrefix(l, "--- "):
+			if p := strings.TrimPrefix(l, "--- "); p != "/dev/null" {
+				path = strings.TrimPrefix(p, "a/")
+			}
+		case !inHunk && strings.HasPrefix(l, "+++ "):
+			if p := strings.TrimPrefix(l, "+++ "); p != "/dev/null" {
+				path = strings.TrimPrefix(p, "b/")
+			}
+		case strings.HasPrefix(l, "@@"):
+			var err error
+			oldLine, oldLeft, newLine, newLeft, err = parseHunkHeader(l)
+			if err != nil {
+				return "", nil, fmt.Errorf("line %d: %v", n+1, err)
+			}
+			inHunk = oldLeft > 0 || newLeft > 0
+			target = nil
+		case inHunk && strings.HasPrefix(l, "+"):
+			target = &gitea.CreatePullReviewComment{Path: path, NewPosition: newLine}
+			newLine++
+			newLeft--
+		case inHunk && strings.HasPrefix(l, "-"):
+			target = &gitea.CreatePullReviewComment{Path: path, OldPosition: oldLine}
+			oldLine++
+			oldLeft--
+		case inHunk && (l == "" || strings.HasPrefix(l, " ")):
+			// gitea positions context lines by the new file,
+			// empty line is context whose space was trimmed by the editor
+			target = &gitea.CreatePullReviewComment{Path: path, NewPosition: newLine}
+			oldLine++
+			newLine++
+			oldLeft--
+			newLeft--
+		default:
+			// file headers, "\ No newline at end of file" etc.
+		}
+		// lines after the counted ones dont belong to the hunk
+		if inHunk && oldLeft <= 0 && newLeft <= 0 {
+			inHunk = false
+		}
+	}
+
+	flush()
+
+	return strings.TrimSpace(strings.Join(body, "\n")), comments, nil
+}
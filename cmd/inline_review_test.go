@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestParseInlineReviewBlankBetweenComments(t *testing.T) {
+	text := `Looks fine overall
+
+diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -10,5 +10,6 @@ func main() {
+ 	a := 1
+-	b := 2
+> why is b gone
+
++	b := 3
++	c := a + b
+> name this sum
+
+ 	fmt.Println(c)
+
+ }
+> closing brace
+
+`
+	body, comments, err := parseInlineReview(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "Looks fine overall" {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	want := []struct {
+		body     string
+		old, new int
+	}{
+		{"why is b gone", 11, 0},
+		{"name this sum", 0, 12},
+		{"closing brace", 0, 15},
+	}
+	if len(comments) != len(want) {
+		t.Fatalf("expected %d comments, got %+v", len(want), comments)
+	}
+	for i, w := range want {
+		c := comments[i]
+		if c.Path != "main.go" || c.Body != w.body || c.OldPosition != w.old || c.NewPosition != w.new {
+			t.Errorf("comment %d: expected %q at -%d +%d, got %+v", i, w.body, w.old, w.new, c)
+		}
+	}
+}
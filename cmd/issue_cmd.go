@@ -0,0 +1,613 @@
+package cmd
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// repo labels together with labels of the owning org
+func getAvailableLabels(repoCtx *gitea.RepoCtx) ([]gitea.Label, error) {
+	labels, err := repoCtx.ListAllLabels()
+	if err != nil {
+		return nil, err
+	}
+	gctx := gitea.Ctx{
+		Token:  repoCtx.Token,
+		ApiUrl: repoCtx.ApiUrl,
+	}
+	orgLabels, err := gctx.ListAllOrgLabels(repoCtx.Owner)
+	if err != nil && !common.IsStatus(err, 404) {
+		return nil, err
+	}
+	return append(labels, orgLabels...), nil
+}
+
+func resolveLabelIDs(repoCtx *gitea.RepoCtx, names []string) ([]int, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	labels, err := getAvailableLabels(repoCtx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(names))
+N:
+	for _, n := range names {
+		for i := range labels {
+			if strings.EqualFold(labels[i].Name, n) {
+				ids = append(ids, labels[i].ID)
+				continue N
+			}
+		}
+		return nil, fmt.Errorf("label %s doesnt exist in %s/%s", n, repoCtx.Owner, repoCtx.Repo)
+	}
+	return ids, nil
+}
+
+func resolveMilestoneID(repoCtx *gitea.RepoCtx, name string) (int, error) {
+	m, err := findMilestone(repoCtx, name)
+	if err != nil {
+		return 0, err
+	}
+	return m.ID, nil
+}
+
+func parseIssueIndex(s string) (int, error) {
+	i, err := strconv.Atoi(strings.TrimPrefix(s, "#"))
+	if err != nil || i <= 0 {
+		return 0, fmt.Errorf("invalid issue index: %s", s)
+	}
+	return i, nil
+}
+
+func issueSelectorOpts(c *common.Config) []CmdOpt {
+	opts := repoInfoOpts(c)
+	return append(opts, addOptWithDefaultVal("issue index", "", []string{"i", "index"}, ""))
+}
+
+func issueLabelNames(is *gitea.Issue) string {
+	n := make([]string, len(is.Labels))
+	for i := range is.Labels {
+		n[i] = is.Labels[i].Name
+	}
+	return strings.Join(n, ",")
+}
+
+func issueAssigneeNames(is *gitea.Issue) string {
+	n := make([]string, len(is.Assignees))
+	for i := range is.Assignees {
+		n[i] = is.Assignees[i].Login
+	}
+	return strings.Join(n, ",")
+}
+
+func printIssueLine(is *gitea.Issue) {
+	milestone := ""
+	if is.Milestone != nil {
+		milestone = is.Milestone.Title
+	}
+	fmt.Printf("issue: index=%d, state=%s, title=%s, user=%s, labels=%s, assignees=%s, milestone=%s, url=%s\n",
+		is.Number, is.State, is.Title, is.User.Login,
+		issueLabelNames(is), issueAssigneeNames(is), milestone, is.HtmlUrl)
+}
+
+func newIssueOpts(c *common.Config) []CmdOpt {
+	// 0, 1
+	opts := repoInfoOpts(c)
+
+	// 2
+	opts = append(opts, addOptWithDefaultVal("issue title", "", []string{"t", "title"}, ""))
+
+	// 3-5
+	opts = append(opts, bodyOpts()...)
+
+	// 6
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"l", "labels"},
+			Label:    "comma separated label names",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 7
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"a", "assignees"},
+			Label:    "comma separated user names",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 8
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"milestone"},
+			Label:    "milestone title",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) NewIssueCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := newIssueOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	title := opts[2].Val.Str
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	body, err := getBody(opts[3:], false, fmt.Sprintf("description of issue: %s", title))
+	if err != nil {
+		return err
+	}
+
+	req := gitea.CreateIssueRequest{
+		Opt: gitea.CreateIssueOption{
+			Title:     title,
+			Body:      body,
+			Assignees: splitList(opts[7].Val.Str),
+		},
+	}
+
+	if req.Opt.Labels, err = resolveLabelIDs(repoCtx, splitList(opts[6].Val.Str)); err != nil {
+		return err
+	}
+
+	if opts[8].Val.Str != "" {
+		if req.Opt.Milestone, err = resolveMilestoneID(repoCtx, opts[8].Val.Str); err != nil {
+			return err
+		}
+	}
+
+	is, err := repoCtx.CreateIssue(&req)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", is.HtmlUrl)
+
+	return nil
+}
+
+func listIssueOpts(c *common.Config) []CmdOpt {
+	// 0, 1
+	opts := repoInfoOpts(c)
+
+	// 2
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"s", "state"},
+			Label:    "open, closed or all [default: open]",
+			NoPrompt: true,
+			DefaultStrFunc: func() (string, error) {
+				return "open", nil
+			},
+		},
+	})
+
+	// 3
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"l", "labels"},
+			Label:    "comma separated label names",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"milestone"},
+			Label:    "comma separated milestone titles",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 5
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"a", "assignee"},
+			Label:    "assigned to user",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 6
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"author"},
+			Label:    "created by user",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 7
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"q", "query"},
+			Label:    "search in title and body",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 8
+	opts = append(opts, formatOpt())
+
+	return opts
+}
+
+func (ctx *CmdCtx) ListIssueCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := listIssueOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	format := opts[8].Val.Str
+	if err := checkFormat(format, formatText, formatJson); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	issues, err := repoCtx.ListAllIssues(&gitea.ListIssuesRequest{
+		State:      opts[2].Val.Str,
+		Labels:     opts[3].Val.Str,
+		Milestones: opts[4].Val.Str,
+		AssignedBy: opts[5].Val.Str,
+		CreatedBy:  opts[6].Val.Str,
+		Query:      opts[7].Val.Str,
+	})
+	if err != nil {
+		return err
+	}
+
+	if format == formatJson {
+		return printJson(issues)
+	}
+
+	for i := range issues {
+		printIssueLine(&issues[i])
+	}
+
+	return nil
+}
+
+func viewIssueOpts(c *common.Config) []CmdOpt {
+	opts := issueSelectorOpts(c)
+	return append(opts, formatOpt())
+}
+
+func (ctx *CmdCtx) ViewIssueCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := viewIssueOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	format := opts[3].Val.Str
+	if err := checkFormat(format, formatText, formatJson); err != nil {
+		return err
+	}
+
+	index, err := parseIssueIndex(opts[2].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	is, err := repoCtx.GetIssue(&gitea.GetIssueRequest{
+		Index: index,
+	})
+	if err != nil {
+		return err
+	}
+
+	comments, err := repoCtx.ListIssueComments(&gitea.ListIssueCommentsRequest{
+		Index: index,
+	})
+	if err != nil {
+		return err
+	}
+
+	if format == formatJson {
+		return printJson(struct {
+			*gitea.Issue
+			CommentList []gitea.Comment `json:"comment_list"`
+		}{is, comments})
+	}
+
+	printIssueLine(is)
+	fmt.Println()
+	if is.Body != "" {
+		fmt.Printf("\t%s\n\n", strings.ReplaceAll(is.Body, "\n", "\n\t"))
+	}
+
+	for i := range comments {
+		t, _ := time.Parse(time.RFC3339, comments[i].CreatedAt)
+		printThreadEntry(&threadEntry{
+			Time:   t,
+			Author: comments[i].User.Login,
+			What:   "commented",
+			Body:   comments[i].Body,
+		})
+	}
+
+	return nil
+}
+
+func updateIssueOpts(c *common.Config) []CmdOpt {
+	// 0-2
+	opts := issueSelectorOpts(c)
+
+	// 3
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"c", "close"},
+			Label:    "close issue",
+			IsBool:   true,
+			NoPrompt: true,
+		},
+	})
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"reopen"},
+			Label:    "reopen issue",
+			IsBool:   true,
+			NoPrompt: true,
+		},
+	})
+
+	// 5
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"rename"},
+			Label:    "Change title",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 6-8
+	opts = append(opts, bodyOpts()...)
+
+	// 9
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"l", "labels"},
+			Label:    "replace labels, comma separated names",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 10
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"a", "assignees"},
+			Label:    "replace assignees, comma separated user names",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 11
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"milestone"},
+			Label:    "milestone title",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) UpdateIssueCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := updateIssueOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	return ctx.updateIssue(opts)
+}
+
+func (ctx *CmdCtx) updateIssue(opts []CmdOpt) error {
+	index, err := parseIssueIndex(opts[2].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	close := opts[3].Val.Bool
+	reopen := opts[4].Val.Bool
+	if close && reopen {
+		return fmt.Errorf("cant both close and reopen issue")
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	req := gitea.EditIssueRequest{
+		Index: index,
+		Opt: gitea.EditIssueOption{
+			Title:     opts[5].Val.Str,
+			Assignees: splitList(opts[10].Val.Str),
+		},
+	}
+
+	if close {
+		req.Opt.State = gitea.Closed
+	}
+	if reopen {
+		req.Opt.State = gitea.Open
+	}
+
+	if opts[6].Val.Str != "" || opts[7].Val.Str != "" || opts[8].Val.Bool {
+		if opts[8].Val.Bool && opts[6].Val.Str == "" && opts[7].Val.Str == "" {
+			// start editing from the current description
+			is, err := repoCtx.GetIssue(&gitea.GetIssueRequest{
+				Index: index,
+			})
+			if err != nil {
+				return err
+			}
+			opts[6].Val.Str = is.Body
+		}
+		body, err := getBody(opts[6:], false, "")
+		if err != nil {
+			return err
+		}
+		req.Opt.Body = &body
+	}
+
+	if opts[11].Val.Str != "" {
+		id, err := resolveMilestoneID(repoCtx, opts[11].Val.Str)
+		if err != nil {
+			return err
+		}
+		req.Opt.Milestone = &id
+	}
+
+	is, err := repoCtx.EditIssue(&req)
+	if err != nil {
+		return err
+	}
+
+	if opts[9].Val.Str != "" {
+		ids, err := resolveLabelIDs(repoCtx, splitList(opts[9].Val.Str))
+		if err != nil {
+			return err
+		}
+		if is.Labels, err = repoCtx.ReplaceIssueLabels(&gitea.ReplaceIssueLabelsRequest{
+			Index: index,
+			Opt: gitea.ReplaceIssueLabelsOption{
+				Labels: ids,
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
+	printIssueLine(is)
+
+	return nil
+}
+
+// close and reopen are shortcuts for update with --close or --reopen
+func (ctx *CmdCtx) setIssueStateCommand(close bool) error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := updateIssueOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	opts[3].Val.Bool = close
+	opts[4].Val.Bool = !close
+
+	return ctx.updateIssue(opts)
+}
+
+func (ctx *CmdCtx) CloseIssueCommand() error {
+	return ctx.setIssueStateCommand(true)
+}
+
+func (ctx *CmdCtx) ReopenIssueCommand() error {
+	return ctx.setIssueStateCommand(false)
+}
+
+func commentIssueOpts(c *common.Config) []CmdOpt {
+	// 0-2
+	opts := issueSelectorOpts(c)
+	// 3-5
+	return append(opts, bodyOpts()...)
+}
+
+func (ctx *CmdCtx) CommentIssueCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := commentIssueOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	index, err := parseIssueIndex(opts[2].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	body, err := getBody(opts[3:], true, fmt.Sprintf("comment on issue #%d", index))
+	if err != nil {
+		return err
+	}
+
+	c, err := repoCtx.CreateIssueComment(&gitea.CreateIssueCommentRequest{
+		Index: index,
+		Opt: gitea.CreateIssueCommentOption{
+			Body: body,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", c.HtmlUrl)
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// issue number at the start of branch name or after slash, eg. feature/42-foo
+var branchIssueRe = regexp.MustCompile(`(?:^|/)(\d+)(?:[-_]|$)`)
+
+// keywords which make gitea close the issue on merge
+var closingKeywordRe = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?)\s+#(\d+)\b`)
+
+func issueFromBranch(branch string) int {
+	m := branchIssueRe.FindStringSubmatch(branch)
+	if m == nil {
+		return 0
+	}
+	i, _ := strconv.Atoi(m[1])
+	return i
+}
+
+// issue numbers referenced by closing keywords
+func linkedIssues(body string) []int {
+	var res []int
+	seen := make(map[int]bool)
+	for _, m := range closingKeywordRe.FindAllStringSubmatch(body, -1) {
+		i, err := strconv.Atoi(m[1])
+		if err != nil || seen[i] {
+			continue
+		}
+		seen[i] = true
+		res = append(res, i)
+	}
+	return res
+}
+
+/*
+issues which pr is going to fix.
+explicit ones must exist, the one guessed from branch name is skipped if it doesnt.
+*/
+func getFixedIssues(repoCtx *gitea.RepoCtx, fixes, head string) ([]int, error) {
+	explicit := true
+	var indexes []int
+
+	for _, f := range splitList(fixes) {
+		i, err := parseIssueIndex(f)
+		if err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, i)
+	}
+
+	if len(indexes) == 0 {
+		if i := issueFromBranch(head); i > 0 {
+			explicit = false
+			indexes = append(indexes, i)
+		}
+	}
+
+	res := make([]int, 0, len(indexes))
+	for _, i := range indexes {
+		is, err := repoCtx.GetIssue(&gitea.GetIssueRequest{
+			Index: i,
+		})
+		if err == nil && is.PullRequest != nil {
+			err = fmt.Errorf("#%d is a pull request, not an issue", i)
+		} else if common.IsStatus(err, 404) {
+			err = fmt.Errorf("issue #%d doesnt exist", i)
+		}
+		if err != nil {
+			if explicit {
+				return nil, err
+			}
+			fmt.Printf("not linking issue guessed from branch name: %v\n", err)
+			continue
+		}
+		fmt.Printf("fixes #%d: %s\n", is.Number, is.Title)
+		res = append(res, i)
+	}
+
+	return res, nil
+}
+
+func closingKeywords(issues []int) string {
+	lines := make([]string, len(issues))
+	for i := range issues {
+		lines[i] = fmt.Sprintf("Closes #%d", issues[i])
+	}
+	return strings.Join(lines, "\n")
+}
+
+/*
+comment on issues linked from merged pr and close them.
+gitea only closes them on merges into default branch, so some may be still open.
+*/
+func closeLinkedIssues(repoCtx *gitea.RepoCtx, pr *gitea.PullRequest) error {
+	for _, i := range linkedIssues(pr.Body) {
+		is, err := repoCtx.GetIssue(&gitea.GetIssueRequest{
+			Index: i,
+		})
+		if err != nil {
+			return err
+		}
+		if is.PullRequest != nil {
+			continue
+		}
+
+		if _, err := repoCtx.CreateIssueComment(&gitea.CreateIssueCommentRequest{
+			Index: i,
+			Opt: gitea.CreateIssueCommentOption{
+				Body: fmt.Sprintf("Fixed by #%d (merged into `%s`)", pr.Number, pr.Base.Ref),
+			},
+		}); err != nil {
+			return err
+		}
+
+		if is.State == gitea.Closed {
+			continue
+		}
+
+		fmt.Printf("closing issue #%d: %s\n", is.Number, is.Title)
+		if _, err := repoCtx.EditIssue(&gitea.EditIssueRequest{
+			Index: i,
+			Opt: gitea.EditIssueOption{
+				State: gitea.Closed,
+			},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,526 @@
+package cmd
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// colors are compared without '#' and case
+func normColor(c string) string {
+	return strings.ToLower(strings.TrimPrefix(c, "#"))
+}
+
+func findLabel(repoCtx *gitea.RepoCtx, name string) (*gitea.Label, error) {
+	labels, err := repoCtx.ListAllLabels()
+	if err != nil {
+		return nil, err
+	}
+	for i := range labels {
+		if labels[i].Name == name {
+			return &labels[i], nil
+		}
+	}
+	return nil, fmt.Errorf("label %s not found in %s/%s", name, repoCtx.Owner, repoCtx.Repo)
+}
+
+func printLabelLine(l *gitea.Label) {
+	fmt.Printf("label: %s color=#%s, exclusive=%t, desc=%s\n",
+		l.Name, normColor(l.Color), l.Exclusive, l.Description)
+}
+
+func listLabelOpts(c *common.Config) []CmdOpt {
+	opts := repoInfoOpts(c)
+	return append(opts, formatOpt())
+}
+
+func (ctx *CmdCtx) ListLabelCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := listLabelOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	format := opts[2].Val.Str
+	if err := checkFormat(format, formatText, formatJson); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	labels, err := repoCtx.ListAllLabels()
+	if err != nil {
+		return err
+	}
+
+	if format == formatJson {
+		return printJson(labels)
+	}
+
+	for i := range labels {
+		printLabelLine(&labels[i])
+	}
+
+	return nil
+}
+
+func newLabelOpts(c *common.Config) []CmdOpt {
+	// 0, 1
+	opts := repoInfoOpts(c)
+
+	// 2
+	opts = append(opts, addOptWithDefaultVal("label name ", "scope/name for scoped labels", []string{"n", "name"}, ""))
+
+	// 3
+	opts = append(opts, addOptWithDefaultVal("label color", "hex, eg. ee0701", []string{"c", "color"}, ""))
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"desc"},
+			Label:    "description [default: empty]",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 5
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"x", "exclusive"},
+			Label:    "only one label of the scope can be set [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) NewLabelCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := newLabelOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	l, err := repoCtx.CreateLabel(&gitea.CreateLabelRequest{
+		Opt: gitea.CreateLabelOption{
+			Name:        opts[2].Val.Str,
+			Color:       "#" + normColor(opts[3].Val.Str),
+			Description: opts[4].Val.Str,
+			Exclusive:   opts[5].Val.Bool,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	printLabelLine(l)
+
+	return nil
+}
+
+func updateLabelOpts(c *common.Config) []CmdOpt {
+	// 0, 1
+	opts := repoInfoOpts(c)
+
+	// 2
+	opts = append(opts, addOptWithDefaultVal("label name ", "", []string{"n", "name"}, ""))
+
+	// 3
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"rename"},
+			Label:    "new name",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"c", "color"},
+			Label:    "new color",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 5
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"desc"},
+			Label:    "new description",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 6
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"x", "exclusive"},
+			Label:    "true or false",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) UpdateLabelCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := updateLabelOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	l, err := findLabel(repoCtx, opts[2].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	req := gitea.EditLabelRequest{
+		ID: l.ID,
+	}
+	if v := opts[3].Val.Str; v != "" {
+		req.Opt.Name = &v
+	}
+	if v := opts[4].Val.Str; v != "" {
+		v = "#" + normColor(v)
+		req.Opt.Color = &v
+	}
+	if v := opts[5].Val.Str; v != "" {
+		req.Opt.Description = &v
+	}
+	if v := opts[6].Val.Str; v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid exclusive value: %s", v)
+		}
+		req.Opt.Exclusive = &b
+	}
+
+	if l, err = repoCtx.EditLabel(&req); err != nil {
+		return err
+	}
+
+	printLabelLine(l)
+
+	return nil
+}
+
+func deleteLabelOpts(c *common.Config) []CmdOpt {
+	// 0, 1
+	opts := repoInfoOpts(c)
+
+	// 2
+	opts = append(opts, addOptWithDefaultVal("label name ", "", []string{"n", "name"}, ""))
+
+	// 3
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"y", "yes"},
+			Label:    "dont ask for confirmation [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) DeleteLabelCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := deleteLabelOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	l, err := findLabel(repoCtx, opts[2].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	if !opts[3].Val.Bool && !confirm(fmt.Sprintf("delete label %s from %s/%s?", l.Name, repoCtx.Owner, repoCtx.Repo)) {
+		return nil
+	}
+
+	return repoCtx.DeleteLabel(&gitea.DeleteLabelRequest{
+		ID: l.ID,
+	})
+}
+
+type labelDef struct {
+	Name        string `yaml:"name"`
+	Color       string `yaml:"color"`
+	Description string `yaml:"description"`
+	Exclusive   bool   `yaml:"exclusive"`
+}
+
+// file passed to label sync
+type labelSpec struct {
+	Labels []labelDef `yaml:"labels"`
+	// remove labels which arent listed
+	DeleteUnlisted bool `yaml:"delete_unlisted"`
+}
+
+type labelChange struct {
+	Action planAction
+	Label  labelDef
+	// id of existing label
+	ID   int
+	Diff []string
+}
+
+func readLabelSpec(path string) (*labelSpec, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec := new(labelSpec)
+	if err := yaml.Unmarshal(b, spec); err != nil {
+		return nil, err
+	}
+	for i := range spec.Labels {
+		if spec.Labels[i].Name == "" {
+			return nil, fmt.Errorf("%s: label %d has no name", path, i)
+		}
+		if spec.Labels[i].Color == "" {
+			return nil, fmt.Errorf("%s: label %s has no color", path, spec.Labels[i].Name)
+		}
+	}
+	return spec, nil
+}
+
+func planLabels(repoCtx *gitea.RepoCtx, spec *labelSpec) ([]labelChange, error) {
+	live, err := repoCtx.ListAllLabels()
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*gitea.Label)
+	for i := range live {
+		byName[live[i].Name] = &live[i]
+	}
+
+	var changes []labelChange
+	wanted := make(map[string]bool)
+
+	for _, want := range spec.Labels {
+		wanted[want.Name] = true
+		l, e := byName[want.Name]
+		if !e {
+			changes = append(changes, labelChange{
+				Action: planCreate,
+				Label:  want,
+			})
+			continue
+		}
+
+		var diff []string
+		if normColor(l.Color) != normColor(want.Color) {
+			diff = append(diff, fmt.Sprintf("color: #%s -> #%s", normColor(l.Color), normColor(want.Color)))
+		}
+		if l.Description != want.Description {
+			diff = append(diff, fmt.Sprintf("description: %q -> %q", l.Description, want.Description))
+		}
+		if l.Exclusive != want.Exclusive {
+			diff = append(diff, fmt.Sprintf("exclusive: %t -> %t", l.Exclusive, want.Exclusive))
+		}
+		if len(diff) > 0 {
+			changes = append(changes, labelChange{
+				Action: planUpdate,
+				Label:  want,
+				ID:     l.ID,
+				Diff:   diff,
+			})
+		}
+	}
+
+	if spec.DeleteUnlisted {
+		for i := range live {
+			if !wanted[live[i].Name] {
+				changes = append(changes, labelChange{
+					Action: planDelete,
+					Label:  labelDef{Name: live[i].Name},
+					ID:     live[i].ID,
+				})
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+func applyLabels(repoCtx *gitea.RepoCtx, changes []labelChange) error {
+	for i := range changes {
+		c := &changes[i]
+		color := "#" + normColor(c.Label.Color)
+		var err error
+		switch c.Action {
+		case planCreate:
+			_, err = repoCtx.CreateLabel(&gitea.CreateLabelRequest{
+				Opt: gitea.CreateLabelOption{
+					Name:        c.Label.Name,
+					Color:       color,
+					Description: c.Label.Description,
+					Exclusive:   c.Label.Exclusive,
+				},
+			})
+		case planUpdate:
+			_, err = repoCtx.EditLabel(&gitea.EditLabelRequest{
+				ID: c.ID,
+				Opt: gitea.EditLabelOption{
+					Color:       &color,
+					Description: &c.Label.Description,
+					Exclusive:   &c.Label.Exclusive,
+				},
+			})
+		case planDelete:
+			err = repoCtx.DeleteLabel(&gitea.DeleteLabelRequest{
+				ID: c.ID,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("%s/%s: label %s: %v", repoCtx.Owner, repoCtx.Repo, c.Label.Name, err)
+		}
+	}
+	return nil
+}
+
+func syncLabelOpts(c *common.Config) []CmdOpt {
+	return []CmdOpt{
+		reposOpt(c),
+		addOptWithDefaultVal("labels file", "yaml with declared labels", []string{"f", "file"}, ""),
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"apply"},
+				Label:    "make the changes, otherwise only plan is printed [default: false]",
+				NoPrompt: true,
+				IsBool:   true,
+			},
+		},
+	}
+}
+
+func (ctx *CmdCtx) SyncLabelCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := syncLabelOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	apply := opts[2].Val.Bool
+
+	spec, err := readLabelSpec(opts[1].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	gctx := ctx.newGiteaCtx()
+	if err := gctx.Validate(); err != nil {
+		return err
+	}
+
+	repos, err := expandRepoGlobs(gctx, splitList(opts[0].Val.Str), nil)
+	if err != nil {
+		return err
+	}
+
+	plans := make([][]labelChange, len(repos))
+	total := 0
+	for i := range repos {
+		r := &repos[i]
+		fmt.Printf("%s:\n", r.FullName)
+		if r.Archived {
+			fmt.Println("    archived, skipping")
+			continue
+		}
+
+		repoCtx := gctx.RepoCtx(r.Owner.Login, r.Name)
+		changes, err := planLabels(repoCtx, spec)
+		if err != nil {
+			return fmt.Errorf("%s: %v", r.FullName, err)
+		}
+
+		if len(changes) == 0 {
+			fmt.Println("    up to date")
+			continue
+		}
+		for j := range changes {
+			fmt.Printf("    %s label %s\n", changes[j].Action, changes[j].Label.Name)
+			for k := range changes[j].Diff {
+				fmt.Printf("        %s\n", changes[j].Diff[k])
+			}
+		}
+		plans[i] = changes
+		total += len(changes)
+	}
+
+	fmt.Printf("\n%d change(s) in %d repositories\n", total, len(repos))
+
+	if total == 0 {
+		return nil
+	}
+
+	if !apply {
+		fmt.Println("run with --apply to make these changes")
+		return nil
+	}
+
+	for i := range repos {
+		if len(plans[i]) == 0 {
+			continue
+		}
+		r := &repos[i]
+		if err := applyLabels(gctx.RepoCtx(r.Owner.Login, r.Name), plans[i]); err != nil {
+			return err
+		}
+		fmt.Printf("%s: applied %d change(s)\n", r.FullName, len(plans[i]))
+	}
+
+	return nil
+}
@@ -0,0 +1,339 @@
+package cmd
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"os"
+	"strconv"
+	"time"
+)
+
+// accepts dates (2006-01-02) and RFC3339, returns RFC3339
+func parseDueDate(s string) (string, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.Format(time.RFC3339), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return "", fmt.Errorf("invalid due date: %s, expected 2006-01-02 or RFC3339", s)
+	}
+	return t.Format(time.RFC3339), nil
+}
+
+// milestone by its title or id
+func findMilestone(repoCtx *gitea.RepoCtx, name string) (*gitea.Milestone, error) {
+	ms, err := repoCtx.ListAllMilestones(&gitea.ListMilestonesRequest{
+		State: "all",
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range ms {
+		if ms[i].Title == name || strconv.Itoa(ms[i].ID) == name {
+			return &ms[i], nil
+		}
+	}
+	return nil, fmt.Errorf("milestone %s doesnt exist in %s/%s", name, repoCtx.Owner, repoCtx.Repo)
+}
+
+func printMilestoneLine(m *gitea.Milestone) {
+	due := m.DueOn
+	if t, err := time.Parse(time.RFC3339, due); err == nil {
+		due = t.Local().Format("2006-01-02")
+	}
+	fmt.Printf("milestone: %s id=%d, state=%s, open=%d, closed=%d, due=%s\n",
+		m.Title, m.ID, m.State, m.OpenIssues, m.ClosedIssues, due)
+}
+
+func listMilestoneOpts(c *common.Config) []CmdOpt {
+	// 0, 1
+	opts := repoInfoOpts(c)
+
+	// 2
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"s", "state"},
+			Label:    "open, closed or all [default: open]",
+			NoPrompt: true,
+			DefaultStrFunc: func() (string, error) {
+				return "open", nil
+			},
+		},
+	})
+
+	// 3
+	opts = append(opts, formatOpt())
+
+	return opts
+}
+
+func (ctx *CmdCtx) ListMilestoneCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := listMilestoneOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	format := opts[3].Val.Str
+	if err := checkFormat(format, formatText, formatJson); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	ms, err := repoCtx.ListAllMilestones(&gitea.ListMilestonesRequest{
+		State: opts[2].Val.Str,
+	})
+	if err != nil {
+		return err
+	}
+
+	if format == formatJson {
+		return printJson(ms)
+	}
+
+	for i := range ms {
+		printMilestoneLine(&ms[i])
+	}
+
+	return nil
+}
+
+func newMilestoneOpts(c *common.Config) []CmdOpt {
+	// 0, 1
+	opts := repoInfoOpts(c)
+
+	// 2
+	opts = append(opts, addOptWithDefaultVal("title      ", "", []string{"t", "title"}, ""))
+
+	// 3
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"desc"},
+			Label:    "description [default: empty]",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"due"},
+			Label:    "due date, 2006-01-02 or RFC3339 [default: none]",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) NewMilestoneCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := newMilestoneOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	req := gitea.CreateMilestoneRequest{
+		Opt: gitea.CreateMilestoneOption{
+			Title:       opts[2].Val.Str,
+			Description: opts[3].Val.Str,
+		},
+	}
+
+	if opts[4].Val.Str != "" {
+		var err error
+		if req.Opt.DueOn, err = parseDueDate(opts[4].Val.Str); err != nil {
+			return err
+		}
+	}
+
+	m, err := repoCtx.CreateMilestone(&req)
+	if err != nil {
+		return err
+	}
+
+	printMilestoneLine(m)
+
+	return nil
+}
+
+func updateMilestoneOpts(c *common.Config) []CmdOpt {
+	// 0, 1
+	opts := repoInfoOpts(c)
+
+	// 2
+	opts = append(opts, addOptWithDefaultVal("milestone  ", "title or id", []string{"t", "title"}, ""))
+
+	// 3
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"rename"},
+			Label:    "new title",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"desc"},
+			Label:    "new description",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 5
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"due"},
+			Label:    "new due date, 2006-01-02 or RFC3339",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 6
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"c", "close"},
+			Label:    "close milestone",
+			IsBool:   true,
+			NoPrompt: true,
+		},
+	})
+
+	// 7
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"reopen"},
+			Label:    "reopen milestone",
+			IsBool:   true,
+			NoPrompt: true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) UpdateMilestoneCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := updateMilestoneOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	if opts[6].Val.Bool && opts[7].Val.Bool {
+		return fmt.Errorf("cant both close and reopen milestone")
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	m, err := findMilestone(repoCtx, opts[2].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	req := gitea.EditMilestoneRequest{
+		ID: m.ID,
+		Opt: gitea.EditMilestoneOption{
+			Title: opts[3].Val.Str,
+		},
+	}
+	if v := opts[4].Val.Str; v != "" {
+		req.Opt.Description = &v
+	}
+	if opts[5].Val.Str != "" {
+		if req.Opt.DueOn, err = parseDueDate(opts[5].Val.Str); err != nil {
+			return err
+		}
+	}
+	if opts[6].Val.Bool {
+		req.Opt.State = "closed"
+	}
+	if opts[7].Val.Bool {
+		req.Opt.State = "open"
+	}
+
+	if m, err = repoCtx.EditMilestone(&req); err != nil {
+		return err
+	}
+
+	printMilestoneLine(m)
+
+	return nil
+}
+
+func deleteMilestoneOpts(c *common.Config) []CmdOpt {
+	// 0, 1
+	opts := repoInfoOpts(c)
+
+	// 2
+	opts = append(opts, addOptWithDefaultVal("milestone  ", "title or id", []string{"t", "title"}, ""))
+
+	// 3
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"y", "yes"},
+			Label:    "dont ask for confirmation [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) DeleteMilestoneCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := deleteMilestoneOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	m, err := findMilestone(repoCtx, opts[2].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	if !opts[3].Val.Bool && !confirm(fmt.Sprintf("delete milestone %s from %s/%s?", m.Title, repoCtx.Owner, repoCtx.Repo)) {
+		return nil
+	}
+
+	return repoCtx.DeleteMilestone(&gitea.DeleteMilestoneRequest{
+		ID: m.ID,
+	})
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/notify"
+	"strings"
+)
+
+type notifyTarget struct {
+	common.NotifyTarget
+	notify.Notifier
+}
+
+// notifiers of every target from config, empty when none is configured
+func newNotifiers(c *common.Config) ([]notifyTarget, error) {
+	targets := c.NotifyTargets()
+	res := make([]notifyTarget, 0, len(targets))
+	for i := range targets {
+		n, err := notify.New(&targets[i], &c.Rocketchat)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, notifyTarget{
+			NotifyTarget: targets[i],
+			Notifier:     n,
+		})
+	}
+	return res, nil
+}
+
+// channel per target key, targets missing here use their default channel
+type notifyChannels map[string]string
+
+/*
+parse comma separated target=channel pairs, eg. rocketchat=dev,matrix=#dev:matrix.org.
+target is name or type of notify target. plain channel without target
+is accepted only when there is single target, so one channel is never
+sent to chats of different kinds.
+*/
+func parseNotifyChannels(c *common.Config, s string) (notifyChannels, error) {
+	targets := c.NotifyTargets()
+	res := make(notifyChannels)
+	for _, e := range splitList(s) {
+		kv := strings.SplitN(e, "=", 2)
+		if len(kv) == 1 {
+			if len(targets) != 1 {
+				return nil, fmt.Errorf("channel %s doesnt say which notify target its for, use target=channel", e)
+			}
+			res[targets[0].Key()] = e
+			continue
+		}
+		known := false
+		for i := range targets {
+			if targets[i].Key() == kv[0] {
+				known = true
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown notify target %s", kv[0])
+		}
+		res[kv[0]] = kv[1]
+	}
+	return res, nil
+}
+
+/*
+post text to every target, header of the target is put before it when withHdr.
+failing target doesnt stop the others, all errors are returned together
+*/
+func postNotification(targets []notifyTarget, channels notifyChannels, text string, withHdr bool) error {
+	var errs []string
+	for _, t := range targets {
+		msg := text
+		if withHdr && t.Header != "" {
+			msg = t.Header + msg
+		}
+		if err := t.Notify(channels[t.Key()], msg); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", t.Key(), err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("couldnt notify %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// prompted only when some target has no default channel
+func notifyChannelOpt(c *common.Config) CmdOpt {
+	optional := true
+	if c != nil {
+		for _, t := range c.NotifyTargets() {
+			if t.NeedsChannel() && t.Channel == "" {
+				optional = false
+			}
+		}
+	}
+	return CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"notify"},
+			Label:    "Notification channels as target=channel pairs, eg. rocketchat=dev,matrix=#dev:matrix.org [default: channel of each notify target]",
+			NoPrompt: optional,
+			Optional: optional,
+		},
+	}
+}
@@ -0,0 +1,433 @@
+package cmd
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"os"
+	"strings"
+)
+
+// units given to new teams when none are specified
+var defaultTeamUnits = []string{
+	"repo.code",
+	"repo.issues",
+	"repo.pulls",
+	"repo.releases",
+	"repo.wiki",
+	"repo.projects",
+}
+
+func orgOpt(c *common.Config) CmdOpt {
+	def := ""
+	if c != nil {
+		def = c.Gitea.DefaultRepoOwner
+	}
+	return addOptWithDefaultVal("organization", "", []string{"org"}, def)
+}
+
+func teamSelectorOpts(c *common.Config) []CmdOpt {
+	return []CmdOpt{
+		// 0
+		orgOpt(c),
+		// 1
+		addOptWithDefaultVal("team name  ", "", []string{"t", "team"}, ""),
+	}
+}
+
+func findTeam(gctx *gitea.Ctx, org, name string) (*gitea.Team, error) {
+	teams, err := gctx.ListAllOrgTeams(org)
+	if err != nil {
+		return nil, err
+	}
+	for i := range teams {
+		if strings.EqualFold(teams[i].Name, name) {
+			return &teams[i], nil
+		}
+	}
+	return nil, fmt.Errorf("team %s not found in %s", name, org)
+}
+
+func printTeamLine(t *gitea.Team) {
+	fmt.Printf("team: %s id=%d, permission=%s, all repos=%t, units=%s, description=%s\n",
+		t.Name, t.ID, t.Permission, t.IncludesAllRepositories, strings.Join(t.Units, ","), t.Description)
+}
+
+func listOrgOpts(c *common.Config) []CmdOpt {
+	return []CmdOpt{
+		// 0
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"a", "all"},
+				Label:    "every visible organization, not only ones youre member of [default: false]",
+				NoPrompt: true,
+				IsBool:   true,
+			},
+		},
+		// 1
+		formatOptOf(formatText, formatJson, formatNames),
+	}
+}
+
+func (ctx *CmdCtx) ListOrgCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := listOrgOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	format := opts[1].Val.Str
+	if err := checkFormat(format, formatText, formatJson, formatNames); err != nil {
+		return err
+	}
+
+	gctx := ctx.newGiteaCtx()
+	if err := gctx.Validate(); err != nil {
+		return err
+	}
+
+	orgs, err := gctx.ListAllOrgs(opts[0].Val.Bool)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case formatJson:
+		return printJson(orgs)
+	case formatNames:
+		for i := range orgs {
+			fmt.Println(orgs[i].Name)
+		}
+	default:
+		for i := range orgs {
+			fmt.Printf("org: %s visibility=%s, full name=%s, description=%s\n",
+				orgs[i].Name, orgs[i].Visibility, orgs[i].FullName, orgs[i].Description)
+		}
+	}
+
+	return nil
+}
+
+func listTeamOpts(c *common.Config) []CmdOpt {
+	return []CmdOpt{orgOpt(c), formatOpt()}
+}
+
+func (ctx *CmdCtx) ListTeamCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := listTeamOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	format := opts[1].Val.Str
+	if err := checkFormat(format, formatText, formatJson); err != nil {
+		return err
+	}
+
+	gctx := ctx.newGiteaCtx()
+	if err := gctx.Validate(); err != nil {
+		return err
+	}
+
+	teams, err := gctx.ListAllOrgTeams(opts[0].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	if format == formatJson {
+		return printJson(teams)
+	}
+
+	for i := range teams {
+		printTeamLine(&teams[i])
+	}
+
+	return nil
+}
+
+func newTeamOpts(c *common.Config) []CmdOpt {
+	// 0
+	opts := []CmdOpt{orgOpt(c)}
+
+	// 1
+	opts = append(opts, addOptWithDefaultVal("team name  ", "", []string{"n", "name"}, ""))
+
+	// 2
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"d", "description"},
+			Label:    "description",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 3
+	opts = append(opts, addOptWithDefaultVal("permission ", "read, write or admin", []string{"p", "permission"}, string(gitea.TeamRead)))
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"units"},
+			Label:    fmt.Sprintf("comma separated units team has access to [default: %s]", strings.Join(defaultTeamUnits, ",")),
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 5
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"allrepos"},
+			Label:    "give access to every repository of organization [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	// 6
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"createrepos"},
+			Label:    "allow members to create repositories in organization [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) NewTeamCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := newTeamOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	perm := gitea.TeamPermission(opts[3].Val.Str)
+	switch perm {
+	case gitea.TeamRead, gitea.TeamWrite, gitea.TeamAdmin:
+	default:
+		return fmt.Errorf("invalid permission %s, expected read, write or admin", perm)
+	}
+
+	units := splitList(opts[4].Val.Str)
+	if len(units) == 0 {
+		units = defaultTeamUnits
+	}
+
+	gctx := ctx.newGiteaCtx()
+	if err := gctx.Validate(); err != nil {
+		return err
+	}
+
+	t, err := gctx.CreateTeam(&gitea.CreateTeamRequest{
+		Org: opts[0].Val.Str,
+		Opt: gitea.CreateTeamOption{
+			Name:                    opts[1].Val.Str,
+			Description:             opts[2].Val.Str,
+			Permission:              perm,
+			Units:                   units,
+			IncludesAllRepositories: opts[5].Val.Bool,
+			CanCreateOrgRepo:        opts[6].Val.Bool,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	printTeamLine(t)
+
+	return nil
+}
+
+func teamListOpts(c *common.Config) []CmdOpt {
+	return append(teamSelectorOpts(c), formatOptOf(formatText, formatJson, formatNames))
+}
+
+func (ctx *CmdCtx) TeamMembersCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := teamListOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	format := opts[2].Val.Str
+	if err := checkFormat(format, formatText, formatJson, formatNames); err != nil {
+		return err
+	}
+
+	gctx := ctx.newGiteaCtx()
+	if err := gctx.Validate(); err != nil {
+		return err
+	}
+
+	t, err := findTeam(gctx, opts[0].Val.Str, opts[1].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	users, err := gctx.ListAllTeamMembers(t.ID)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case formatJson:
+		return printJson(users)
+	case formatNames:
+		for i := range users {
+			fmt.Println(users[i].Login)
+		}
+	default:
+		for i := range users {
+			fmt.Printf("member: %s full name=%s, email=%s\n", users[i].Login, users[i].FullName, users[i].Email)
+		}
+	}
+
+	return nil
+}
+
+func (ctx *CmdCtx) TeamReposCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := teamListOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	format := opts[2].Val.Str
+	if err := checkFormat(format, formatText, formatJson, formatNames); err != nil {
+		return err
+	}
+
+	gctx := ctx.newGiteaCtx()
+	if err := gctx.Validate(); err != nil {
+		return err
+	}
+
+	t, err := findTeam(gctx, opts[0].Val.Str, opts[1].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	repos, err := gctx.ListAllTeamRepos(t.ID)
+	if err != nil {
+		return err
+	}
+
+	return printRepos(repos, format)
+}
+
+func teamMemberOpts(c *common.Config) []CmdOpt {
+	return append(teamSelectorOpts(c),
+		addOptWithDefaultVal("users      ", "comma separated usernames", []string{"u", "users"}, ""))
+}
+
+func teamRepoOpts(c *common.Config) []CmdOpt {
+	return append(teamSelectorOpts(c),
+		addOptWithDefaultVal("repositories", "comma separated repository names of organization", []string{"repos"}, ""))
+}
+
+// run change for every element of list in opts[2] for team selected by opts[0-1]
+func (ctx *CmdCtx) changeTeam(opts []CmdOpt, change func(gctx *gitea.Ctx, org string, t *gitea.Team, item string) error) error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	org := opts[0].Val.Str
+	items := splitList(opts[2].Val.Str)
+
+	gctx := ctx.newGiteaCtx()
+	if err := gctx.Validate(); err != nil {
+		return err
+	}
+
+	t, err := findTeam(gctx, org, opts[1].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := change(gctx, org, t, item); err != nil {
+			return fmt.Errorf("%s: %v", item, err)
+		}
+	}
+
+	return nil
+}
+
+func (ctx *CmdCtx) AddTeamMemberCommand() error {
+	return ctx.changeTeam(teamMemberOpts(ctx.Config), func(gctx *gitea.Ctx, org string, t *gitea.Team, user string) error {
+		if err := gctx.AddTeamMember(&gitea.TeamMemberRequest{
+			TeamID:   t.ID,
+			Username: user,
+		}); err != nil {
+			return err
+		}
+		fmt.Printf("added %s to %s/%s\n", user, org, t.Name)
+		return nil
+	})
+}
+
+func (ctx *CmdCtx) RemoveTeamMemberCommand() error {
+	return ctx.changeTeam(teamMemberOpts(ctx.Config), func(gctx *gitea.Ctx, org string, t *gitea.Team, user string) error {
+		if err := gctx.RemoveTeamMember(&gitea.TeamMemberRequest{
+			TeamID:   t.ID,
+			Username: user,
+		}); err != nil {
+			return err
+		}
+		fmt.Printf("removed %s from %s/%s\n", user, org, t.Name)
+		return nil
+	})
+}
+
+func (ctx *CmdCtx) AddTeamRepoCommand() error {
+	return ctx.changeTeam(teamRepoOpts(ctx.Config), func(gctx *gitea.Ctx, org string, t *gitea.Team, repo string) error {
+		repo = strings.TrimPrefix(repo, org+"/")
+		if err := gctx.AddTeamRepo(&gitea.TeamRepoRequest{
+			TeamID: t.ID,
+			Org:    org,
+			Repo:   repo,
+		}); err != nil {
+			return err
+		}
+		fmt.Printf("team %s got %s access to %s/%s\n", t.Name, t.Permission, org, repo)
+		return nil
+	})
+}
+
+func (ctx *CmdCtx) RemoveTeamRepoCommand() error {
+	return ctx.changeTeam(teamRepoOpts(ctx.Config), func(gctx *gitea.Ctx, org string, t *gitea.Team, repo string) error {
+		repo = strings.TrimPrefix(repo, org+"/")
+		if err := gctx.RemoveTeamRepo(&gitea.TeamRepoRequest{
+			TeamID: t.ID,
+			Org:    org,
+			Repo:   repo,
+		}); err != nil {
+			return err
+		}
+		fmt.Printf("team %s lost access to %s/%s\n", t.Name, org, repo)
+		return nil
+	})
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	formatText = "text"
+	formatJson = "json"
+	// one name per line, for piping into other commands
+	formatNames = "names"
+	formatCsv   = "csv"
+)
+
+func formatOpt() CmdOpt {
+	return formatOptOf(formatText, formatJson)
+}
+
+// format option listing given formats, first one is the default
+func formatOptOf(formats ...string) CmdOpt {
+	return CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"format"},
+			Label:    fmt.Sprintf("output format: %s [default: %s]", strings.Join(formats, ", "), formats[0]),
+			NoPrompt: true,
+			DefaultStrFunc: func() (string, error) {
+				return formats[0], nil
+			},
+		},
+	}
+}
+
+func checkFormat(f string, allowed ...string) error {
+	for i := range allowed {
+		if f == allowed[i] {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid format: %s", f)
+}
+
+func printJson(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printCsv(header []string, rows [][]string) error {
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return w.Error()
+}
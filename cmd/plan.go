@@ -0,0 +1,10 @@
+package cmd
+
+// kind of change in plans printed by sync commands
+type planAction string
+
+const (
+	planCreate planAction = "+ create"
+	planUpdate planAction = "~ update"
+	planDelete planAction = "- delete"
+)
@@ -0,0 +1,313 @@
+package cmd
+
+import (
+	"fmt"
+	"gitea-cli/gitea"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// one entry of rules file passed to protect apply
+type protectionTarget struct {
+	// owner/repo globs
+	Repos   []string `yaml:"repos"`
+	Exclude []string `yaml:"exclude"`
+	// remove live rules which arent listed for the repository
+	DeleteUnlisted bool                     `yaml:"delete_unlisted"`
+	Rules          []gitea.BranchProtection `yaml:"rules"`
+}
+
+type protectionChange struct {
+	Action planAction
+	Rule   gitea.BranchProtection
+	// field changes for updates
+	Diff []string
+}
+
+// sorted copy, whitelists are compared regardless of order
+func sortedStrings(v reflect.Value) []string {
+	res := make([]string, v.Len())
+	for i := range res {
+		res[i] = v.Index(i).String()
+	}
+	sort.Strings(res)
+	return res
+}
+
+// list fields which differ between live and wanted rule
+func diffBranchProtection(live, want *gitea.BranchProtection) []string {
+	lv := reflect.ValueOf(*live)
+	wv := reflect.ValueOf(*want)
+	t := lv.Type()
+
+	var diff []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Name == "RuleName" || f.Name == "BranchName" {
+			continue
+		}
+
+		a := lv.Field(i).Interface()
+		b := wv.Field(i).Interface()
+		if f.Type.Kind() == reflect.Slice {
+			a = sortedStrings(lv.Field(i))
+			b = sortedStrings(wv.Field(i))
+		}
+
+		if !reflect.DeepEqual(a, b) {
+			name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+			diff = append(diff, fmt.Sprintf("%s: %v -> %v", name, a, b))
+		}
+	}
+
+	return diff
+}
+
+// compare wanted rules with the ones on server
+func planBranchProtection(repoCtx *gitea.RepoCtx, want []gitea.BranchProtection, deleteUnlisted bool) ([]protectionChange, error) {
+	live, err := repoCtx.ListBranchProtections()
+	if err != nil {
+		return nil, err
+	}
+
+	liveByName := make(map[string]*gitea.BranchProtection)
+	for i := range live {
+		liveByName[live[i].Name()] = &live[i]
+	}
+
+	var changes []protectionChange
+	wanted := make(map[string]bool)
+
+	for i := range want {
+		name := want[i].Name()
+		wanted[name] = true
+
+		l, e := liveByName[name]
+		if !e {
+			changes = append(changes, protectionChange{
+				Action: planCreate,
+				Rule:   want[i],
+			})
+			continue
+		}
+
+		if diff := diffBranchProtection(l, &want[i]); len(diff) > 0 {
+			changes = append(changes, protectionChange{
+				Action: planUpdate,
+				Rule:   want[i],
+				Diff:   diff,
+			})
+		}
+	}
+
+	if deleteUnlisted {
+		for i := range live {
+			if !wanted[live[i].Name()] {
+				changes = append(changes, protectionChange{
+					Action: planDelete,
+					Rule:   live[i],
+				})
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+func printProtectionPlan(changes []protectionChange, indent string) {
+	if len(changes) == 0 {
+		fmt.Printf("%sup to date\n", indent)
+		return
+	}
+	for i := range changes {
+		fmt.Printf("%s%s rule %s\n", indent, changes[i].Action, changes[i].Rule.Name())
+		for j := range changes[i].Diff {
+			fmt.Printf("%s    %s\n", indent, changes[i].Diff[j])
+		}
+	}
+}
+
+// copy of rule with nil lists replaced by empty ones,
+// gitea leaves a list unchanged when it is sent as null
+func protectionPayload(rule gitea.BranchProtection) gitea.BranchProtection {
+	v := reflect.ValueOf(&rule).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if f.Kind() == reflect.Slice && f.IsNil() {
+			f.Set(reflect.MakeSlice(f.Type(), 0, 0))
+		}
+	}
+	return rule
+}
+
+func applyBranchProtection(repoCtx *gitea.RepoCtx, changes []protectionChange) error {
+	for i := range changes {
+		c := &changes[i]
+		var err error
+		switch c.Action {
+		case planCreate:
+			_, err = repoCtx.CreateBranchProtection(&gitea.CreateBranchProtectionRequest{
+				Opt: protectionPayload(c.Rule),
+			})
+		case planUpdate:
+			_, err = repoCtx.EditBranchProtection(&gitea.EditBranchProtectionRequest{
+				Name: c.Rule.Name(),
+				Opt:  protectionPayload(c.Rule),
+			})
+		case planDelete:
+			err = repoCtx.DeleteBranchProtection(&gitea.DeleteBranchProtectionRequest{
+				Name: c.Rule.Name(),
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("%s/%s: rule %s: %v", repoCtx.Owner, repoCtx.Repo, c.Rule.Name(), err)
+		}
+	}
+	return nil
+}
+
+func readProtectionTargets(path string) ([]protectionTarget, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var targets []protectionTarget
+	if err := yaml.Unmarshal(b, &targets); err != nil {
+		return nil, err
+	}
+	for i := range targets {
+		if len(targets[i].Repos) == 0 {
+			return nil, fmt.Errorf("%s: entry %d has no repos", path, i)
+		}
+		for j := range targets[i].Rules {
+			if targets[i].Rules[j].Name() == "" {
+				return nil, fmt.Errorf("%s: entry %d, rule %d has no rule_name", path, i, j)
+			}
+		}
+	}
+	return targets, nil
+}
+
+func protectApplyOpts() []CmdOpt {
+	return []CmdOpt{
+		addOptWithDefaultVal("rules file ", "yaml list of repos and rules", []string{"f", "file"}, ""),
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"apply"},
+				Label:    "make the changes, otherwise only plan is printed [default: false]",
+				NoPrompt: true,
+				IsBool:   true,
+			},
+		},
+	}
+}
+
+func (ctx *CmdCtx) ProtectApplyCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := protectApplyOpts()
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	file := opts[0].Val.Str
+	apply := opts[1].Val.Bool
+
+	targets, err := readProtectionTargets(file)
+	if err != nil {
+		return err
+	}
+
+	gctx := ctx.newGiteaCtx()
+	if err := gctx.Validate(); err != nil {
+		return err
+	}
+
+	type repoRules struct {
+		Repo           gitea.Repository
+		Rules          []gitea.BranchProtection
+		DeleteUnlisted bool
+	}
+
+	// later entries override rules with the same name
+	var order []string
+	byRepo := make(map[string]*repoRules)
+
+	for i := range targets {
+		repos, err := expandRepoGlobs(gctx, targets[i].Repos, targets[i].Exclude)
+		if err != nil {
+			return err
+		}
+		for j := range repos {
+			name := repos[j].FullName
+			rr, e := byRepo[name]
+			if !e {
+				rr = &repoRules{Repo: repos[j]}
+				byRepo[name] = rr
+				order = append(order, name)
+			}
+			rr.DeleteUnlisted = rr.DeleteUnlisted || targets[i].DeleteUnlisted
+		R:
+			for k := range targets[i].Rules {
+				rule := targets[i].Rules[k]
+				for l := range rr.Rules {
+					if rr.Rules[l].Name() == rule.Name() {
+						rr.Rules[l] = rule
+						continue R
+					}
+				}
+				rr.Rules = append(rr.Rules, rule)
+			}
+		}
+	}
+
+	plans := make(map[string][]protectionChange)
+	total := 0
+
+	for _, name := range order {
+		rr := byRepo[name]
+		fmt.Printf("%s:\n", name)
+		if rr.Repo.Archived {
+			fmt.Println("    archived, skipping")
+			continue
+		}
+		changes, err := planBranchProtection(gctx.RepoCtx(rr.Repo.Owner.Login, rr.Repo.Name), rr.Rules, rr.DeleteUnlisted)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		printProtectionPlan(changes, "    ")
+		plans[name] = changes
+		total += len(changes)
+	}
+
+	fmt.Printf("\n%d change(s) in %d repositories\n", total, len(order))
+
+	if total == 0 {
+		return nil
+	}
+
+	if !apply {
+		fmt.Println("run with --apply to make these changes")
+		return nil
+	}
+
+	for _, name := range order {
+		if len(plans[name]) == 0 {
+			continue
+		}
+		rr := byRepo[name]
+		if err := applyBranchProtection(gctx.RepoCtx(rr.Repo.Owner.Login, rr.Repo.Name), plans[name]); err != nil {
+			return err
+		}
+		fmt.Printf("%s: applied %d change(s)\n", name, len(plans[name]))
+	}
+
+	return nil
+}
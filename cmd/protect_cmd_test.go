@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"encoding/json"
+	"gitea-cli/gitea"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestProtectionPayloadClearsOmittedLists(t *testing.T) {
+	spec := `
+rule_name: master
+enable_push: true
+enable_push_whitelist: true
+push_whitelist_teams: [owners]
+required_approvals: 1
+`
+	var rule gitea.BranchProtection
+	if err := yaml.Unmarshal([]byte(spec), &rule); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(protectionPayload(rule))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(b)
+
+	for _, f := range []string{
+		"push_whitelist_usernames",
+		"merge_whitelist_usernames",
+		"merge_whitelist_teams",
+		"status_check_contexts",
+		"approvals_whitelist_username",
+		"approvals_whitelist_teams",
+	} {
+		if !strings.Contains(s, `"`+f+`":[]`) {
+			t.Errorf("%s is not sent as empty list: %s", f, s)
+		}
+	}
+	if !strings.Contains(s, `"push_whitelist_teams":["owners"]`) {
+		t.Errorf("push_whitelist_teams changed: %s", s)
+	}
+	if strings.Contains(s, "null") {
+		t.Errorf("payload has null: %s", s)
+	}
+}
@@ -0,0 +1,548 @@
+package cmd
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// prints upload progress of wrapped reader to stderr
+type progressReader struct {
+	r     io.Reader
+	name  string
+	total int64
+	read  int64
+	last  int64
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	pct := int64(100)
+	if p.total > 0 {
+		pct = p.read * 100 / p.total
+	}
+	if pct != p.last || err == io.EOF {
+		p.last = pct
+		fmt.Fprintf(os.Stderr, "\r%s: %3d%% (%d/%d bytes)", p.name, pct, p.read, p.total)
+	}
+	return n, err
+}
+
+func uploadReleaseAssets(repoCtx *gitea.RepoCtx, release *gitea.Release, paths []string) error {
+	for _, path := range paths {
+		fp, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		st, err := fp.Stat()
+		if err != nil {
+			fp.Close()
+			return err
+		}
+
+		name := filepath.Base(path)
+		a, err := repoCtx.CreateReleaseAsset(&gitea.CreateReleaseAssetRequest{
+			ReleaseID: release.ID,
+			Name:      name,
+			Content: &progressReader{
+				r:     fp,
+				name:  name,
+				total: st.Size(),
+				last:  -1,
+			},
+		})
+		fp.Close()
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		fmt.Printf("asset: %s size=%d, url=%s\n", a.Name, a.Size, a.BrowserDownloadUrl)
+	}
+	return nil
+}
+
+func printReleaseLine(r *gitea.Release) {
+	fmt.Printf("release: %s name=%s, draft=%t, prerelease=%t, assets=%d, date=%s, url=%s\n",
+		r.TagName, r.Name, r.Draft, r.Prerelease, len(r.Assets), r.PublishedAt, r.HtmlUrl)
+}
+
+func releaseSelectorOpts(c *common.Config) []CmdOpt {
+	opts := repoInfoOpts(c)
+	return append(opts, addOptWithDefaultVal("tag        ", "", []string{"t", "tag"}, ""))
+}
+
+func findRelease(repoCtx *gitea.RepoCtx, tag string) (*gitea.Release, error) {
+	r, err := repoCtx.GetReleaseByTag(&gitea.GetReleaseByTagRequest{
+		Tag: tag,
+	})
+	if common.IsStatus(err, 404) {
+		// drafts arent returned by tag
+		rs, err := repoCtx.ListAllReleases()
+		if err != nil {
+			return nil, err
+		}
+		for i := range rs {
+			if rs[i].TagName == tag {
+				return &rs[i], nil
+			}
+		}
+		return nil, fmt.Errorf("release for tag %s not found", tag)
+	}
+	return r, err
+}
+
+func assetsOpt() CmdOpt {
+	return CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"a", "assets"},
+			Label:    "comma separated files to upload",
+			Optional: true,
+			NoPrompt: true,
+		},
+	}
+}
+
+func newReleaseOpts(c *common.Config) []CmdOpt {
+	// 0-2
+	opts := releaseSelectorOpts(c)
+
+	// 3
+	def := ""
+	if c != nil {
+		def = c.Gitea.DefaultBaseForPR
+	}
+	opts = append(opts, addOptWithDefaultVal("target     ", "branch or commit for new tag", []string{"target"}, def))
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"n", "name"},
+			Label:    "release title [default: tag]",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 5-7
+	opts = append(opts, bodyOpts()...)
+
+	// 8
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"g", "generate"},
+			Label:    "generate notes from prs merged since previous tag [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	// 9
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"draft"},
+			Label:    "create as draft [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	// 10
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"pre", "prerelease"},
+			Label:    "mark as prerelease [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	// 11
+	opts = append(opts, assetsOpt())
+
+	return opts
+}
+
+// create tag on server unless it exists already
+func ensureTag(repoCtx *gitea.RepoCtx, tag, target, message string) error {
+	_, err := repoCtx.GetTag(&gitea.GetTagRequest{
+		Tag: tag,
+	})
+	if err == nil || !common.IsStatus(err, 404) {
+		return err
+	}
+	fmt.Printf("creating tag %s on %s\n", tag, target)
+	_, err = repoCtx.CreateTag(&gitea.CreateTagRequest{
+		Opt: gitea.CreateTagOption{
+			TagName: tag,
+			Target:  target,
+			Message: message,
+		},
+	})
+	return err
+}
+
+func (ctx *CmdCtx) NewReleaseCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := newReleaseOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	tag := opts[2].Val.Str
+	target := opts[3].Val.Str
+	name := opts[4].Val.Str
+	generate := opts[8].Val.Bool
+	assets := splitList(opts[11].Val.Str)
+
+	if name == "" {
+		name = tag
+	}
+
+	for _, a := range assets {
+		if _, err := os.Stat(a); err != nil {
+			return err
+		}
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	if generate && opts[5].Val.Str == "" && opts[6].Val.Str == "" {
+		// new tag doesnt exist yet so latest one reachable from target is the previous
+		notes, err := buildChangelog(ctx.Config, repoCtx, latestTag(target), target, tag, "")
+		if err != nil {
+			return err
+		}
+		opts[5].Val.Str = notes
+	}
+
+	body, err := getBody(opts[5:], false, fmt.Sprintf("notes for release %s", tag))
+	if err != nil {
+		return err
+	}
+
+	if err := ensureTag(repoCtx, tag, target, name); err != nil {
+		return err
+	}
+
+	r, err := repoCtx.CreateRelease(&gitea.CreateReleaseRequest{
+		Opt: gitea.CreateReleaseOption{
+			TagName:         tag,
+			TargetCommitish: target,
+			Name:            name,
+			Body:            body,
+			Draft:           opts[9].Val.Bool,
+			Prerelease:      opts[10].Val.Bool,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	printReleaseLine(r)
+
+	return uploadReleaseAssets(repoCtx, r, assets)
+}
+
+func listReleaseOpts(c *common.Config) []CmdOpt {
+	opts := repoInfoOpts(c)
+	return append(opts, formatOpt())
+}
+
+func (ctx *CmdCtx) ListReleaseCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := listReleaseOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	format := opts[2].Val.Str
+	if err := checkFormat(format, formatText, formatJson); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	rs, err := repoCtx.ListAllReleases()
+	if err != nil {
+		return err
+	}
+
+	if format == formatJson {
+		return printJson(rs)
+	}
+
+	for i := range rs {
+		printReleaseLine(&rs[i])
+	}
+
+	return nil
+}
+
+func (ctx *CmdCtx) PublishReleaseCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := releaseSelectorOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	r, err := findRelease(repoCtx, opts[2].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	draft := false
+	if r, err = repoCtx.EditRelease(&gitea.EditReleaseRequest{
+		ID: r.ID,
+		Opt: gitea.EditReleaseOption{
+			Draft: &draft,
+		},
+	}); err != nil {
+		return err
+	}
+
+	printReleaseLine(r)
+
+	return nil
+}
+
+func deleteReleaseOpts(c *common.Config) []CmdOpt {
+	// 0-2
+	opts := releaseSelectorOpts(c)
+
+	// 3
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"withtag"},
+			Label:    "delete the tag as well [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"y", "yes"},
+			Label:    "dont ask for confirmation [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) DeleteReleaseCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := deleteReleaseOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	tag := opts[2].Val.Str
+	withTag := opts[3].Val.Bool
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	r, err := findRelease(repoCtx, tag)
+	if err != nil {
+		return err
+	}
+
+	what := "release"
+	if withTag {
+		what = "release and tag"
+	}
+	if !opts[4].Val.Bool && !confirm(fmt.Sprintf("delete %s %s from %s/%s?", what, tag, repoCtx.Owner, repoCtx.Repo)) {
+		return nil
+	}
+
+	if err := repoCtx.DeleteRelease(&gitea.DeleteReleaseRequest{
+		ID: r.ID,
+	}); err != nil {
+		return err
+	}
+
+	if withTag {
+		return repoCtx.DeleteTag(&gitea.DeleteTagRequest{
+			Tag: tag,
+		})
+	}
+
+	return nil
+}
+
+func uploadReleaseOpts(c *common.Config) []CmdOpt {
+	opts := releaseSelectorOpts(c)
+	asset := assetsOpt()
+	asset.Spec.Optional = false
+	asset.Spec.NoPrompt = false
+	return append(opts, asset)
+}
+
+func (ctx *CmdCtx) UploadReleaseCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := uploadReleaseOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	r, err := findRelease(repoCtx, opts[2].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	return uploadReleaseAssets(repoCtx, r, splitList(opts[3].Val.Str))
+}
+
+func listAssetOpts(c *common.Config) []CmdOpt {
+	opts := releaseSelectorOpts(c)
+	return append(opts, formatOpt())
+}
+
+func (ctx *CmdCtx) ListAssetCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := listAssetOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	format := opts[3].Val.Str
+	if err := checkFormat(format, formatText, formatJson); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	r, err := findRelease(repoCtx, opts[2].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	assets, err := repoCtx.ListReleaseAssets(&gitea.ListReleaseAssetsRequest{
+		ReleaseID: r.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if format == formatJson {
+		return printJson(assets)
+	}
+
+	for i := range assets {
+		fmt.Printf("asset: %s id=%d, size=%d, downloads=%d, url=%s\n",
+			assets[i].Name, assets[i].ID, assets[i].Size, assets[i].DownloadCount, assets[i].BrowserDownloadUrl)
+	}
+
+	return nil
+}
+
+func deleteAssetOpts(c *common.Config) []CmdOpt {
+	// 0-2
+	opts := releaseSelectorOpts(c)
+
+	// 3
+	opts = append(opts, addOptWithDefaultVal("asset name ", "", []string{"n", "name"}, ""))
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"y", "yes"},
+			Label:    "dont ask for confirmation [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) DeleteAssetCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := deleteAssetOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	name := opts[3].Val.Str
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	r, err := findRelease(repoCtx, opts[2].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	assets, err := repoCtx.ListReleaseAssets(&gitea.ListReleaseAssetsRequest{
+		ReleaseID: r.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range assets {
+		if assets[i].Name != name {
+			continue
+		}
+		if !opts[4].Val.Bool && !confirm(fmt.Sprintf("delete asset %s of release %s?", name, r.TagName)) {
+			return nil
+		}
+		return repoCtx.DeleteReleaseAsset(&gitea.DeleteReleaseAssetRequest{
+			ReleaseID: r.ID,
+			AssetID:   assets[i].ID,
+		})
+	}
+
+	return fmt.Errorf("asset %s not found in release %s", name, r.TagName)
+}
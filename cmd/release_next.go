@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	bumpPatch = "patch"
+	bumpMinor = "minor"
+	bumpMajor = "major"
+)
+
+var bumpRank = map[string]int{
+	bumpPatch: 1,
+	bumpMinor: 2,
+	bumpMajor: 3,
+}
+
+// release tags, prereleases and build metadata are not considered
+var semverTagRe = regexp.MustCompile(`^(v?)(\d+)\.(\d+)\.(\d+)$`)
+
+type semver struct {
+	Prefix              string
+	Major, Minor, Patch int
+}
+
+func parseSemver(s string) (semver, bool) {
+	m := semverTagRe.FindStringSubmatch(s)
+	if m == nil {
+		return semver{}, false
+	}
+	v := semver{Prefix: m[1]}
+	v.Major, _ = strconv.Atoi(m[2])
+	v.Minor, _ = strconv.Atoi(m[3])
+	v.Patch, _ = strconv.Atoi(m[4])
+	return v, true
+}
+
+func (v semver) String() string {
+	return fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+}
+
+func (v semver) Less(o semver) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	return v.Patch < o.Patch
+}
+
+func (v semver) Bump(part string) semver {
+	switch part {
+	case bumpMajor:
+		return semver{Prefix: v.Prefix, Major: v.Major + 1}
+	case bumpMinor:
+		return semver{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor + 1}
+	default:
+		return semver{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+}
+
+// highest semver tag reachable from sha, nil if there is none.
+// tags on other branches, eg. v2.0.0 when releasing from release/1.x, are skipped
+func latestSemverTag(repoCtx *gitea.RepoCtx, sha string) (*gitea.Tag, semver, error) {
+	tags, err := repoCtx.ListAllTags()
+	if err != nil {
+		return nil, semver{}, err
+	}
+	type verTag struct {
+		tag *gitea.Tag
+		ver semver
+	}
+	var cand []verTag
+	for i := range tags {
+		if v, ok := parseSemver(tags[i].Name); ok {
+			cand = append(cand, verTag{&tags[i], v})
+		}
+	}
+	sort.Slice(cand, func(i, j int) bool {
+		return cand[j].ver.Less(cand[i].ver)
+	})
+	for _, c := range cand {
+		if _, err := gitOutput("cat-file", "-e", c.tag.Commit.Sha+"^{commit}"); err != nil {
+			return nil, semver{}, fmt.Errorf("commit %s of tag %s isnt fetched: git fetch --tags", c.tag.Commit.Sha, c.tag.Name)
+		}
+		if _, err := gitOutput("merge-base", "--is-ancestor", c.tag.Commit.Sha, sha); err == nil {
+			return c.tag, c.ver, nil
+		}
+	}
+	return nil, semver{}, nil
+}
+
+// bump required by pr and labels which caused it
+func prBump(cfg common.Changelog, pr *gitea.PullRequest) (string, []string) {
+	bump := ""
+	var reasons []string
+	for _, s := range cfg.Sections {
+		if bumpRank[s.Bump] == 0 {
+			continue
+		}
+		for _, l := range pr.Labels {
+			if !containsFold(s.Labels, l.Name) {
+				continue
+			}
+			reasons = append(reasons, l.Name)
+			if bumpRank[s.Bump] > bumpRank[bump] {
+				bump = s.Bump
+			}
+		}
+	}
+	if bump == "" {
+		bump = cfg.DefaultBump
+		if bumpRank[bump] == 0 {
+			bump = bumpPatch
+		}
+	}
+	return bump, reasons
+}
+
+func nextReleaseOpts(c *common.Config) []CmdOpt {
+	// 0-1
+	opts := repoInfoOpts(c)
+
+	// 2
+	def := ""
+	if c != nil {
+		def = c.Gitea.DefaultBaseForPR
+	}
+	opts = append(opts, addOptWithDefaultVal("target     ", "branch or commit to release", []string{"target"}, def))
+
+	// 3
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"tag"},
+			Label:    "create annotated tag with computed version [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"m", "message"},
+			Label:    "tag message [default: release <version>]",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) NextReleaseCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := nextReleaseOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	target := opts[2].Val.Str
+	createTag := opts[3].Val.Bool
+	message := opts[4].Val.Str
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	// tag has to land on the commit which was analysed, not on wherever target points on the server
+	sha, err := gitOutput("rev-parse", "--verify", "--quiet", target+"^{commit}")
+	if err != nil {
+		return fmt.Errorf("couldnt resolve %s to a commit", target)
+	}
+	fmt.Printf("target: %s (%s)\n", target, sha)
+
+	latest, ver, err := latestSemverTag(repoCtx, sha)
+	if err != nil {
+		return err
+	}
+
+	from := ""
+	if latest != nil {
+		from = latest.Name
+		fmt.Printf("latest version: %s (%s)\n", latest.Name, latest.Commit.Sha)
+	} else {
+		ver = semver{Prefix: "v"}
+		fmt.Printf("no version tags found, starting from %s\n", ver)
+	}
+
+	prs, err := getMergedPrsBetween(repoCtx, from, sha)
+	if err != nil {
+		if from != "" {
+			return fmt.Errorf("%v\nmake sure %s and %s are fetched: git fetch --tags", err, from, target)
+		}
+		return err
+	}
+
+	if len(prs) == 0 {
+		fmt.Printf("no prs merged into %s since %s, nothing to release\n", target, ver)
+		return nil
+	}
+
+	cfg := getChangelogConfig(ctx.Config)
+	bump := ""
+	var decisive *gitea.PullRequest
+	fmt.Printf("prs merged since %s:\n", ver)
+	for i := range prs {
+		b, reasons := prBump(cfg, &prs[i])
+		why := "no matching labels"
+		if len(reasons) > 0 {
+			why = "labels: " + strings.Join(reasons, ", ")
+		}
+		fmt.Printf("  #%d %s -> %s (%s)\n", prs[i].Number, prs[i].Title, b, why)
+		if bumpRank[b] > bumpRank[bump] {
+			bump, decisive = b, &prs[i]
+		}
+	}
+
+	next := ver.Bump(bump)
+	fmt.Printf("bump: %s because of #%d %s\n", bump, decisive.Number, decisive.Title)
+	fmt.Printf("next version: %s\n", next)
+
+	if !createTag {
+		return nil
+	}
+
+	if message == "" {
+		message = "release " + next.String()
+	}
+
+	tag, err := repoCtx.CreateTag(&gitea.CreateTagRequest{
+		Opt: gitea.CreateTagOption{
+			TagName: next.String(),
+			Target:  sha,
+			Message: message,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created tag %s on %s\n", tag.Name, tag.Commit.Sha)
+
+	return nil
+}
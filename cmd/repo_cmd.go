@@ -0,0 +1,747 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	protoHttps = "https"
+	protoSsh   = "ssh"
+)
+
+func printRepoLine(r *gitea.Repository) {
+	flags := make([]string, 0, 4)
+	if r.Private {
+		flags = append(flags, "private")
+	}
+	if r.Fork {
+		flags = append(flags, "fork")
+	}
+	if r.Mirror {
+		flags = append(flags, "mirror")
+	}
+	if r.Archived {
+		flags = append(flags, "archived")
+	}
+	if r.Template {
+		flags = append(flags, "template")
+	}
+	fmt.Printf("repo: %s [%s] %s\n", r.FullName, strings.Join(flags, ","), r.Description)
+}
+
+func repoCloneUrl(r *gitea.Repository, proto string) (string, error) {
+	switch proto {
+	case "", protoHttps:
+		return r.CloneUrl, nil
+	case protoSsh:
+		return r.SshUrl, nil
+	}
+	return "", fmt.Errorf("invalid clone protocol '%s', expected %s or %s", proto, protoHttps, protoSsh)
+}
+
+func viewRepoOpts(c *common.Config) []CmdOpt {
+	opts := repoInfoOpts(c)
+	return append(opts, formatOpt())
+}
+
+func (ctx *CmdCtx) ViewRepoCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := viewRepoOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	format := opts[2].Val.Str
+	if err := checkFormat(format, formatText, formatJson); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	r, err := repoCtx.GetRepo()
+	if err != nil {
+		return err
+	}
+
+	if format == formatJson {
+		return printJson(r)
+	}
+
+	printRepoLine(r)
+	if r.Parent != nil {
+		fmt.Printf("forked from: %s\n", r.Parent.FullName)
+	}
+	fmt.Printf("default branch: %s\n", r.DefaultBranch)
+	fmt.Printf("stars: %d, forks: %d, open issues: %d, open prs: %d\n",
+		r.StarsCount, r.ForksCount, r.OpenIssuesCount, r.OpenPrCounter)
+	fmt.Printf("size: %d KiB\n", r.Size)
+	fmt.Printf("created: %s, updated: %s\n", r.CreatedAt, r.UpdatedAt)
+	fmt.Printf("url: %s\n", r.HtmlUrl)
+	fmt.Printf("clone: %s\n", r.CloneUrl)
+	fmt.Printf("ssh: %s\n", r.SshUrl)
+
+	return nil
+}
+
+func newRepoOpts(c *common.Config) []CmdOpt {
+	opts := make([]CmdOpt, 0, 11)
+
+	// 0
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"org"},
+			Label:    "create in organization [default: current user]",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 1
+	opts = append(opts, addOptWithDefaultVal("repo name  ", "", []string{"n", "name"}, ""))
+
+	// 2
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"d", "description"},
+			Label:    "description",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 3
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"private"},
+			Label:    "make repository private [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"template"},
+			Label:    "mark repository as template [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	// 5
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"from"},
+			Label:    "generate from template repository owner/repo",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 6
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"b", "branch"},
+			Label:    "default branch [default: server default]",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 7
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"init"},
+			Label:    "initialize repository with readme [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	// 8
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"gitignores"},
+			Label:    "comma separated gitignore templates, implies --init",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 9
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"license"},
+			Label:    "license template, e.g. MIT, implies --init",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) NewRepoCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := newRepoOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	org := opts[0].Val.Str
+	name := opts[1].Val.Str
+	from := opts[5].Val.Str
+	gitignores := opts[8].Val.Str
+	license := opts[9].Val.Str
+	init := opts[7].Val.Bool || gitignores != "" || license != ""
+
+	gctx := ctx.newGiteaCtx()
+	if err := gctx.Validate(); err != nil {
+		return err
+	}
+
+	var (
+		r   *gitea.Repository
+		err error
+	)
+
+	if from != "" {
+		parts := strings.SplitN(from, "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid template repository '%s', expected owner/repo", from)
+		}
+		if init || opts[4].Val.Bool {
+			return fmt.Errorf("--from cannot be combined with --init, --gitignores, --license or --template")
+		}
+		owner := org
+		if owner == "" {
+			u, err := gctx.GetCurrentUser()
+			if err != nil {
+				return err
+			}
+			owner = u.Login
+		}
+		r, err = gctx.RepoCtx(parts[0], parts[1]).GenerateRepo(&gitea.GenerateRepoRequest{
+			Opt: gitea.GenerateRepoOption{
+				Owner:         owner,
+				Name:          name,
+				Description:   opts[2].Val.Str,
+				Private:       opts[3].Val.Bool,
+				DefaultBranch: opts[6].Val.Str,
+				GitContent:    true,
+				Topics:        true,
+				Labels:        true,
+			},
+		})
+	} else {
+		readme := ""
+		if init {
+			readme = "Default"
+		}
+		r, err = gctx.CreateRepo(&gitea.CreateRepoRequest{
+			Org: org,
+			Opt: gitea.CreateRepoOption{
+				Name:          name,
+				Description:   opts[2].Val.Str,
+				Private:       opts[3].Val.Bool,
+				Template:      opts[4].Val.Bool,
+				AutoInit:      init,
+				Gitignores:    gitignores,
+				License:       license,
+				Readme:        readme,
+				DefaultBranch: opts[6].Val.Str,
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	printRepoLine(r)
+	fmt.Printf("url: %s\n", r.HtmlUrl)
+
+	return nil
+}
+
+func forkRepoOpts(c *common.Config) []CmdOpt {
+	// 0-1
+	opts := repoInfoOpts(c)
+
+	// 2
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"org"},
+			Label:    "fork into organization [default: current user]",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 3
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"n", "name"},
+			Label:    "name of the fork [default: same as repo]",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"c", "clone"},
+			Label:    "clone the fork afterwards [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) ForkRepoCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := forkRepoOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	r, err := repoCtx.CreateFork(&gitea.CreateForkRequest{
+		Opt: gitea.CreateForkOption{
+			Organization: opts[2].Val.Str,
+			Name:         opts[3].Val.Str,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	printRepoLine(r)
+
+	if !opts[4].Val.Bool {
+		return nil
+	}
+
+	return cloneRepo(r, ctx.Config.Gitea.CloneProtocol, "")
+}
+
+// clone repository, forks get their parent added as upstream remote
+func cloneRepo(r *gitea.Repository, proto, dir string) error {
+	u, err := repoCloneUrl(r, proto)
+	if err != nil {
+		return err
+	}
+
+	if dir == "" {
+		dir = r.Name
+	}
+
+	if err := gitRun("clone", u, dir); err != nil {
+		return err
+	}
+
+	if r.Parent == nil {
+		return nil
+	}
+
+	pu, err := repoCloneUrl(r.Parent, proto)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("adding upstream remote %s\n", pu)
+	return gitRun("-C", dir, "remote", "add", "upstream", pu)
+}
+
+func cloneRepoOpts(c *common.Config) []CmdOpt {
+	// 0-1
+	opts := repoInfoOpts(c)
+
+	// 2
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"d", "dir"},
+			Label:    "directory to clone into [default: repo name]",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 3
+	def := protoHttps
+	if c != nil && c.Gitea.CloneProtocol != "" {
+		def = c.Gitea.CloneProtocol
+	}
+	opts = append(opts, addOptWithDefaultVal("protocol   ", protoHttps+" or "+protoSsh, []string{"protocol"}, def))
+
+	return opts
+}
+
+func (ctx *CmdCtx) CloneRepoCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := cloneRepoOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	r, err := repoCtx.GetRepo()
+	if err != nil {
+		return err
+	}
+
+	return cloneRepo(r, opts[3].Val.Str, opts[2].Val.Str)
+}
+
+func setRepoArchived(ctx *CmdCtx, archived bool) error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := repoInfoOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	r, err := repoCtx.EditRepo(&gitea.EditRepoRequest{
+		Opt: gitea.EditRepoOption{
+			Archived: &archived,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	printRepoLine(r)
+
+	return nil
+}
+
+func (ctx *CmdCtx) ArchiveRepoCommand() error {
+	return setRepoArchived(ctx, true)
+}
+
+func (ctx *CmdCtx) UnarchiveRepoCommand() error {
+	return setRepoArchived(ctx, false)
+}
+
+func (ctx *CmdCtx) DeleteRepoCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := repoInfoOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	// make sure it exists before asking
+	r, err := repoCtx.GetRepo()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("this will permanently delete %s including its issues, prs and wiki.\n", r.FullName)
+	fmt.Printf("type the full repository name to confirm: ")
+	typed, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.TrimSpace(typed) != r.FullName {
+		return fmt.Errorf("confirmation didnt match, %s was not deleted", r.FullName)
+	}
+
+	if err := repoCtx.DeleteRepo(); err != nil {
+		return err
+	}
+
+	fmt.Printf("deleted %s\n", r.FullName)
+
+	return nil
+}
+
+type repoFilter struct {
+	Topic    string
+	Language string
+	// nil means any
+	Archived *bool
+	Private  *bool
+	Fork     *bool
+	Mirror   *bool
+}
+
+// true/false or empty for any
+func parseTriState(name, s string) (*bool, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s value '%s', expected true or false", name, s)
+	}
+	return &b, nil
+}
+
+func (f *repoFilter) match(r *gitea.Repository) bool {
+	if f.Topic != "" && !containsFold(r.Topics, f.Topic) {
+		return false
+	}
+	if f.Language != "" && !strings.EqualFold(r.Language, f.Language) {
+		return false
+	}
+	for _, c := range []struct {
+		want *bool
+		got  bool
+	}{
+		{f.Archived, r.Archived},
+		{f.Private, r.Private},
+		{f.Fork, r.Fork},
+		{f.Mirror, r.Mirror},
+	} {
+		if c.want != nil && *c.want != c.got {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *repoFilter) apply(repos []gitea.Repository) []gitea.Repository {
+	res := make([]gitea.Repository, 0, len(repos))
+	for i := range repos {
+		if f.match(&repos[i]) {
+			res = append(res, repos[i])
+		}
+	}
+	return res
+}
+
+var repoFilterFlags = []string{"archived", "private", "fork", "mirror"}
+
+// topic, language and then repoFilterFlags
+func repoFilterOpts() []CmdOpt {
+	opts := []CmdOpt{
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"topic"},
+				Label:    "only repositories with this topic",
+				Optional: true,
+				NoPrompt: true,
+			},
+		}, {
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"language"},
+				Label:    "only repositories with this primary language",
+				Optional: true,
+				NoPrompt: true,
+			},
+		},
+	}
+	for _, f := range repoFilterFlags {
+		opts = append(opts, CmdOpt{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{f},
+				Label:    fmt.Sprintf("true or false to filter by %s flag [default: any]", f),
+				Optional: true,
+				NoPrompt: true,
+			},
+		})
+	}
+	return opts
+}
+
+func readRepoFilter(opts []CmdOpt) (*repoFilter, error) {
+	f := &repoFilter{
+		Topic:    opts[0].Val.Str,
+		Language: opts[1].Val.Str,
+	}
+	dst := []**bool{&f.Archived, &f.Private, &f.Fork, &f.Mirror}
+	for i, name := range repoFilterFlags {
+		v, err := parseTriState(name, opts[2+i].Val.Str)
+		if err != nil {
+			return nil, err
+		}
+		*dst[i] = v
+	}
+	return f, nil
+}
+
+func printRepos(repos []gitea.Repository, format string) error {
+	switch format {
+	case formatJson:
+		return printJson(repos)
+	case formatNames:
+		for i := range repos {
+			fmt.Println(repos[i].FullName)
+		}
+	default:
+		for i := range repos {
+			printRepoLine(&repos[i])
+		}
+	}
+	return nil
+}
+
+func listRepoOpts(c *common.Config) []CmdOpt {
+	// 0
+	opts := []CmdOpt{
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"o", "owner"},
+				Label:    "organization or user [default: repositories of current user]",
+				Optional: true,
+				NoPrompt: true,
+			},
+		},
+	}
+
+	// 1-6
+	opts = append(opts, repoFilterOpts()...)
+
+	// 7
+	return append(opts, formatOptOf(formatText, formatJson, formatNames))
+}
+
+func (ctx *CmdCtx) ListRepoCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := listRepoOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	owner := opts[0].Val.Str
+	format := opts[7].Val.Str
+	if err := checkFormat(format, formatText, formatJson, formatNames); err != nil {
+		return err
+	}
+
+	filter, err := readRepoFilter(opts[1:7])
+	if err != nil {
+		return err
+	}
+
+	gctx := ctx.newGiteaCtx()
+	if err := gctx.Validate(); err != nil {
+		return err
+	}
+
+	var repos []gitea.Repository
+	if owner == "" {
+		repos, err = gctx.ListAllCurrentUserRepos()
+	} else {
+		repos, err = gctx.ListAllOwnerRepos(owner)
+	}
+	if err != nil {
+		return err
+	}
+
+	return printRepos(filter.apply(repos), format)
+}
+
+func searchRepoOpts(c *common.Config) []CmdOpt {
+	// 0
+	opts := []CmdOpt{
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"q", "query"},
+				Label:    "keyword matched against name and topics [default: everything]",
+				Optional: true,
+				NoPrompt: true,
+			},
+		},
+	}
+
+	// 1-6
+	opts = append(opts, repoFilterOpts()...)
+
+	// 7
+	return append(opts, formatOptOf(formatText, formatJson, formatNames))
+}
+
+func (ctx *CmdCtx) SearchRepoCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := searchRepoOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	format := opts[7].Val.Str
+	if err := checkFormat(format, formatText, formatJson, formatNames); err != nil {
+		return err
+	}
+
+	filter, err := readRepoFilter(opts[1:7])
+	if err != nil {
+		return err
+	}
+
+	gctx := ctx.newGiteaCtx()
+	if err := gctx.Validate(); err != nil {
+		return err
+	}
+
+	// let server narrow results down where it can, rest is filtered here
+	req := gitea.SearchReposRequest{
+		Query:    opts[0].Val.Str,
+		Private:  filter.Private,
+		Archived: filter.Archived,
+	}
+	if req.Query == "" && filter.Topic != "" {
+		req.Query, req.Topic = filter.Topic, true
+	}
+	if filter.Fork != nil && *filter.Fork {
+		req.Mode = "fork"
+	} else if filter.Mirror != nil && *filter.Mirror {
+		req.Mode = "mirror"
+	}
+
+	repos, err := gctx.SearchAllRepos(&req)
+	if err != nil {
+		return err
+	}
+
+	return printRepos(filter.apply(repos), format)
+}
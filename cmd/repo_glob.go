@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"path"
+	"strings"
+)
+
+func matchAnyGlob(globs []string, name string) bool {
+	for i := range globs {
+		if ok, _ := path.Match(globs[i], name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+expand owner/repo globs into list of repositories.
+when owner is literal only repositories of that org/user are listed,
+otherwise every repository visible to the user is searched.
+repositories matching any of exclude globs are left out.
+*/
+func expandRepoGlobs(gctx *gitea.Ctx, globs, exclude []string) ([]gitea.Repository, error) {
+	var (
+		all     []gitea.Repository
+		byOwner = make(map[string][]gitea.Repository)
+		seen    = make(map[string]bool)
+		res     = make([]gitea.Repository, 0, len(globs))
+		err     error
+	)
+
+	for _, g := range globs {
+		parts := strings.SplitN(g, "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid repo pattern '%s', expected owner/repo", g)
+		}
+		if _, err := path.Match(g, ""); err != nil {
+			return nil, fmt.Errorf("invalid repo pattern '%s': %v", g, err)
+		}
+
+		var candidates []gitea.Repository
+		if strings.ContainsAny(parts[0], "*?[\\") {
+			if all == nil {
+				all, err = gctx.SearchAllRepos(&gitea.SearchReposRequest{})
+				if err != nil {
+					return nil, err
+				}
+			}
+			candidates = all
+		} else {
+			c, e := byOwner[parts[0]]
+			if !e {
+				c, err = gctx.ListAllOwnerRepos(parts[0])
+				if err != nil {
+					return nil, err
+				}
+				byOwner[parts[0]] = c
+			}
+			candidates = c
+		}
+
+		for i := range candidates {
+			name := candidates[i].FullName
+			if seen[name] {
+				continue
+			}
+			if ok, _ := path.Match(g, name); !ok || matchAnyGlob(exclude, name) {
+				continue
+			}
+			seen[name] = true
+			res = append(res, candidates[i])
+		}
+	}
+
+	return res, nil
+}
+
+// comma separated owner/repo globs, default repository from config if set
+func reposOpt(c *common.Config) CmdOpt {
+	def := ""
+	if c != nil && c.Gitea.DefaultRepoOwner != "" && c.Gitea.DefaultRepoName != "" {
+		def = c.Gitea.DefaultRepoOwner + "/" + c.Gitea.DefaultRepoName
+	}
+	return addOptWithDefaultVal("repositories", "comma separated owner/repo globs, eg. myorg/*", []string{"repos"}, def)
+}
@@ -0,0 +1,330 @@
+package cmd
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func prSelectorOpts(c *common.Config) []CmdOpt {
+	opts := repoInfoOpts(c)
+	return append(opts, findPrOpts()...)
+}
+
+func reviewPrOpts(c *common.Config) []CmdOpt {
+	// 0-3
+	opts := prSelectorOpts(c)
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"a", "approve"},
+			Label:    "approve pull request",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	// 5
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"x", "reject", "requestchanges"},
+			Label:    "request changes",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	// 6
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"c", "comment"},
+			Label:    "comment without approving or requesting changes",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	// 7-9
+	opts = append(opts, bodyOpts()...)
+
+	// 10
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"l", "inline"},
+			Label:    "comment on diff lines in $EDITOR, review is a comment unless approved or rejected",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) ReviewPrCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := reviewPrOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	var events []gitea.ReviewState
+	if opts[4].Val.Bool {
+		events = append(events, gitea.ReviewApproved)
+	}
+	if opts[5].Val.Bool {
+		events = append(events, gitea.ReviewRequestChanges)
+	}
+	if opts[6].Val.Bool {
+		events = append(events, gitea.ReviewComment)
+	}
+	inline := opts[10].Val.Bool
+	if inline && len(events) == 0 {
+		events = append(events, gitea.ReviewComment)
+	}
+	if len(events) != 1 {
+		return fmt.Errorf("exactly one of --approve, --reject, --comment is required")
+	}
+	event := events[0]
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	pr, err := findPr(repoCtx, opts[2].Val.Str, opts[3].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	var (
+		body     string
+		comments []gitea.CreatePullReviewComment
+	)
+
+	if inline {
+		body, comments, err = ctx.editInlineReview(repoCtx, &pr, opts[7:])
+	} else {
+		hint := fmt.Sprintf("%s review for PR #%d: %s", event, pr.Number, pr.Title)
+		body, err = getBody(opts[7:], event != gitea.ReviewApproved, hint)
+	}
+	if err != nil {
+		return err
+	}
+
+	if event != gitea.ReviewApproved && body == "" && len(comments) == 0 {
+		return fmt.Errorf("empty review, aborting")
+	}
+
+	review, err := repoCtx.CreateReview(&gitea.CreateReviewRequest{
+		Index: pr.Number,
+		Opt: gitea.CreatePullReviewOptions{
+			Body:     body,
+			Event:    event,
+			CommitID: pr.Head.Sha,
+			Comments: comments,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("review: id=%d, state=%s, url=%s\n", review.ID, review.State, review.HtmlUrl)
+
+	return nil
+}
+
+// open pr diff in editor and parse comments written by the reviewer
+func (ctx *CmdCtx) editInlineReview(repoCtx *gitea.RepoCtx, pr *gitea.PullRequest, bodyOpts []CmdOpt) (string, []gitea.CreatePullReviewComment, error) {
+	body, err := getBody([]CmdOpt{bodyOpts[0], bodyOpts[1], {}}, false, "")
+	if err != nil {
+		return "", nil, err
+	}
+
+	diff, err := repoCtx.GetPRDiff(&gitea.GetPRDiffRequest{
+		Index: pr.Number,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	text, err := editText(inlineReviewTemplate(body, diff), fmt.Sprintf(inlineReviewHint, pr.Number, pr.Title))
+	if err != nil {
+		return "", nil, err
+	}
+
+	body, comments, err := parseInlineReview(text)
+	if err != nil {
+		return "", nil, err
+	}
+
+	fmt.Printf("%d inline comment(s)\n", len(comments))
+
+	return body, comments, nil
+}
+
+func (ctx *CmdCtx) ListReviewsCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := prSelectorOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	pr, err := findPr(repoCtx, opts[2].Val.Str, opts[3].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	reviews, err := repoCtx.ListReviews(&gitea.ListReviewsRequest{
+		Index: pr.Number,
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range reviews {
+		r := &reviews[i]
+		flags := make([]string, 0, 3)
+		if r.Official {
+			flags = append(flags, "official")
+		}
+		if r.Stale {
+			flags = append(flags, "stale")
+		}
+		if r.Dismissed {
+			flags = append(flags, "dismissed")
+		}
+		fmt.Printf("review: id=%d, user=%s, state=%s, date=%s, flags=%s\n",
+			r.ID, r.User.Login, r.State, r.SubmittedAt, strings.Join(flags, ","))
+		if r.Body != "" {
+			fmt.Printf("\t%s\n", strings.ReplaceAll(r.Body, "\n", "\n\t"))
+		}
+	}
+
+	return nil
+}
+
+func dismissReviewOpts(c *common.Config) []CmdOpt {
+	// 0-3
+	opts := prSelectorOpts(c)
+
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"id"},
+			Label:    "id of review to dismiss",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	// 5
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"s", "stale"},
+			Label:    "dismiss every stale approval or change request",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+
+	// 6
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"m", "message"},
+			Label:    "reason for dismissal [default: empty]",
+			Optional: true,
+			NoPrompt: true,
+		},
+	})
+
+	return opts
+}
+
+func (ctx *CmdCtx) DismissReviewCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := dismissReviewOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	idStr := opts[4].Val.Str
+	stale := opts[5].Val.Bool
+	msg := opts[6].Val.Str
+
+	if (idStr == "") == !stale {
+		return fmt.Errorf("either --id or --stale is required")
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	pr, err := findPr(repoCtx, opts[2].Val.Str, opts[3].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	var ids []int
+	if idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			return fmt.Errorf("invalid review id: %s", idStr)
+		}
+		ids = append(ids, id)
+	} else {
+		reviews, err := repoCtx.ListReviews(&gitea.ListReviewsRequest{
+			Index: pr.Number,
+		})
+		if err != nil {
+			return err
+		}
+		for i := range reviews {
+			r := &reviews[i]
+			if !r.Stale || r.Dismissed {
+				continue
+			}
+			if r.State != gitea.ReviewApproved && r.State != gitea.ReviewRequestChanges {
+				continue
+			}
+			ids = append(ids, r.ID)
+		}
+	}
+
+	for i := range ids {
+		fmt.Printf("dismissing review %d\n", ids[i])
+		if err := repoCtx.DismissReview(&gitea.DismissReviewRequest{
+			Index:    pr.Number,
+			ReviewID: ids[i],
+			Opt: gitea.DismissPullReviewOptions{
+				Message: msg,
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
+	if len(ids) == 0 {
+		fmt.Println("no stale reviews")
+	}
+
+	return nil
+}
@@ -36,7 +36,7 @@ func Run() {
 
 	_ = ctx
 
-	nc := make(chan os.Signal)
+	nc := make(chan os.Signal, 1)
 	signal.Notify(nc, os.Interrupt, syscall.SIGINT)
 	go func() {
 		<-nc
@@ -45,7 +45,7 @@ func Run() {
 		os.Exit(0)
 	}()
 
-	args := FilterArgs(os.Args[1:])
+	args := FilterArgs(commandArgs(os.Args[1:]))
 
 	c := ctx.CommandRoot.FindInChain(args)
 	if c == nil {
@@ -0,0 +1,321 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// max accepted payload size
+const maxHookBody = 10 << 20
+
+/*
+header with event name, more specific than X-Gitea-Event:
+review requests come as pull_request_review_request, reviews as
+pull_request_review_{approved,rejected,comment} and comments on
+pull requests as pull_request_comment
+*/
+func hookEventName(r *http.Request) string {
+	if e := r.Header.Get("X-Gitea-Event-Type"); e != "" {
+		return e
+	}
+	return r.Header.Get("X-Gitea-Event")
+}
+
+func mdLink(text, url string) string {
+	return fmt.Sprintf("[%s](%s)", text, url)
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// chat message for event, empty if event isnt worth posting
+func formatHookEvent(event string, p *gitea.HookPayload) string {
+	repo := ""
+	if p.Repository != nil {
+		repo = p.Repository.FullName
+	}
+	who := ""
+	if p.Sender != nil {
+		who = p.Sender.Login
+	}
+
+	switch event {
+	case "pull_request", "pull_request_review_request":
+		if p.PullRequest == nil {
+			return ""
+		}
+		pr := p.PullRequest
+		link := mdLink(fmt.Sprintf("%s#%d %s", repo, pr.Number, pr.Title), pr.Url)
+		switch p.Action {
+		case "opened":
+			return fmt.Sprintf("%s opened PR %s (*%s* -> *%s*)", who, link, pr.HeadName(), pr.Base.Ref)
+		case "closed":
+			if pr.Merged {
+				return fmt.Sprintf("%s merged PR %s (*%s* -> *%s*)", who, link, pr.HeadName(), pr.Base.Ref)
+			}
+			return fmt.Sprintf("%s closed PR %s", who, link)
+		case "reopened":
+			return fmt.Sprintf("%s reopened PR %s", who, link)
+		case "review_requested":
+			if p.RequestedReviewer != nil {
+				return fmt.Sprintf("%s requested review from %s on PR %s", who, p.RequestedReviewer.Login, link)
+			}
+		}
+
+	case "pull_request_review_approved", "pull_request_review_rejected", "pull_request_review_comment":
+		if p.PullRequest == nil {
+			return ""
+		}
+		pr := p.PullRequest
+		link := mdLink(fmt.Sprintf("%s#%d %s", repo, pr.Number, pr.Title), pr.Url)
+		verb := "reviewed"
+		switch event {
+		case "pull_request_review_approved":
+			verb = "approved"
+		case "pull_request_review_rejected":
+			verb = "requested changes on"
+		case "pull_request_review_comment":
+			verb = "commented on"
+		}
+		msg := fmt.Sprintf("%s %s PR %s", who, verb, link)
+		if p.Review != nil && p.Review.Content != "" {
+			msg += "\n> " + firstLine(p.Review.Content)
+		}
+		return msg
+
+	case "issues":
+		if p.Issue == nil {
+			return ""
+		}
+		link := mdLink(fmt.Sprintf("%s#%d %s", repo, p.Issue.Number, p.Issue.Title), p.Issue.HtmlUrl)
+		switch p.Action {
+		case "opened", "closed", "reopened":
+			return fmt.Sprintf("%s %s issue %s", who, p.Action, link)
+		}
+
+	case "issue_comment", "pull_request_comment":
+		if p.Issue == nil || p.Comment == nil || p.Action != "created" {
+			return ""
+		}
+		kind := "issue"
+		if event == "pull_request_comment" || p.Issue.PullRequest != nil {
+			kind = "PR"
+		}
+		link := mdLink(fmt.Sprintf("%s#%d %s", repo, p.Issue.Number, p.Issue.Title), p.Comment.HtmlUrl)
+		return fmt.Sprintf("%s commented on %s %s\n> %s", who, kind, link, firstLine(p.Comment.Body))
+
+	case "push":
+		if len(p.Commits) == 0 {
+			return ""
+		}
+		branch := strings.TrimPrefix(p.Ref, "refs/heads/")
+		msg := fmt.Sprintf("%s pushed %s to *%s* in %s",
+			who, mdLink(fmt.Sprintf("%d commit(s)", len(p.Commits)), p.CompareUrl), branch, repo)
+		for i, c := range p.Commits {
+			if i == 5 {
+				msg += fmt.Sprintf("\n... and %d more", len(p.Commits)-i)
+				break
+			}
+			msg += fmt.Sprintf("\n- %s %s", mdLink(fmt.Sprintf("%.7s", c.ID), c.Url), firstLine(c.Message))
+		}
+		return msg
+
+	case "release":
+		if p.Release == nil || p.Action != "published" {
+			return ""
+		}
+		return fmt.Sprintf("%s published release %s of %s", who, mdLink(p.Release.TagName, p.Release.HtmlUrl), repo)
+
+	case "status":
+		// only failures are interesting
+		if p.State != "failure" && p.State != "error" {
+			return ""
+		}
+		return fmt.Sprintf("%s %s in %s for %.7s: %s", p.Context, p.State, repo, p.Sha, mdLink(p.Description, p.TargetUrl))
+	}
+
+	return ""
+}
+
+/*
+channels of every notify target event should be posted to, by target key.
+empty channel is default channel of the target, it is used for targets
+not listed by any matching route and when no route matches at all.
+*/
+func routeHookEvent(c *common.Config, event, repo string) (map[string][]string, error) {
+	targets := c.NotifyTargets()
+	res := make(map[string][]string)
+	seen := make(map[string]bool)
+	add := func(channels notifyChannels) {
+		for i := range targets {
+			k := targets[i].Key()
+			ch := channels[k]
+			if !seen[k+"="+ch] {
+				seen[k+"="+ch] = true
+				res[k] = append(res[k], ch)
+			}
+		}
+	}
+
+	matched := false
+	for _, r := range c.HookServer.Routes {
+		if len(r.Events) > 0 && !containsFold(r.Events, event) {
+			continue
+		}
+		if len(r.Repos) > 0 && !matchAnyGlob(r.Repos, repo) {
+			continue
+		}
+		channels, err := parseNotifyChannels(c, r.Channel)
+		if err != nil {
+			return nil, err
+		}
+		add(channels)
+		matched = true
+	}
+	if !matched {
+		add(nil)
+	}
+	return res, nil
+}
+
+type hookHandler struct {
+	config  *common.Config
+	secret  string
+	targets []notifyTarget
+}
+
+func (h *hookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxHookBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if h.secret != "" && !gitea.VerifyHookSignature(h.secret, body, r.Header.Get("X-Gitea-Signature")) {
+		fmt.Fprintf(os.Stderr, "rejected delivery %s from %s: invalid signature\n",
+			r.Header.Get("X-Gitea-Delivery"), r.RemoteAddr)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	event := hookEventName(r)
+	p := new(gitea.HookPayload)
+	if err := json.Unmarshal(body, p); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	msg := formatHookEvent(event, p)
+	if msg == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	repo := ""
+	if p.Repository != nil {
+		repo = p.Repository.FullName
+	}
+
+	routes, err := routeHookEvent(h.config, event, repo)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// one failing chat shouldnt keep the event from the others
+	failed := false
+	for _, t := range h.targets {
+		for _, ch := range routes[t.Key()] {
+			name := t.Key()
+			if ch != "" {
+				name += "=" + ch
+			}
+			fmt.Printf("%s %s %s -> %s\n", event, p.Action, repo, name)
+			if err := t.Notify(ch, msg); err != nil {
+				fmt.Fprintf(os.Stderr, "couldnt post %s event to %s: %v\n", event, name, err)
+				failed = true
+			}
+		}
+	}
+
+	if failed {
+		http.Error(w, "couldnt relay event", http.StatusBadGateway)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func serveHooksOpts(c *common.Config) []CmdOpt {
+	def := ":8080"
+	if c != nil && c.HookServer.Listen != "" {
+		def = c.HookServer.Listen
+	}
+	return []CmdOpt{
+		addOptWithDefaultVal("listen addr", "", []string{"l", "listen"}, def),
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"insecure"},
+				Label:    "accept unsigned deliveries when hook_server.secret is empty [default: false]",
+				NoPrompt: true,
+				IsBool:   true,
+			},
+		},
+	}
+}
+
+// relay gitea webhook deliveries to notify targets
+func (ctx *CmdCtx) ServeHooksCommand() error {
+	targets, err := newNotifiers(ctx.Config)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no notify targets or rocketchat in config")
+	}
+	for i, r := range ctx.Config.HookServer.Routes {
+		if _, err := parseNotifyChannels(ctx.Config, r.Channel); err != nil {
+			return fmt.Errorf("hook_server.routes[%d]: %v", i, err)
+		}
+	}
+
+	opts := serveHooksOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	addr := opts[0].Val.Str
+	insecure := opts[1].Val.Bool
+
+	h := &hookHandler{
+		config:  ctx.Config,
+		secret:  os.ExpandEnv(ctx.Config.HookServer.Secret),
+		targets: targets,
+	}
+
+	if h.secret == "" {
+		if !insecure {
+			return fmt.Errorf("hook_server.secret is empty, set it or pass --insecure to accept unsigned deliveries")
+		}
+		fmt.Fprintln(os.Stderr, "warning: hook_server.secret is not set, signatures wont be verified")
+	}
+
+	fmt.Printf("listening for gitea webhooks on %s\n", addr)
+
+	return http.ListenAndServe(addr, h)
+}
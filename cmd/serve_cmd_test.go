@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"gitea-cli/common"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type recordNotifier struct {
+	msgs []string
+}
+
+func (n *recordNotifier) Notify(channel, text string) error {
+	n.msgs = append(n.msgs, text)
+	return nil
+}
+
+// payloads below are trimmed deliveries of gitea 1.21, headers as gitea sends them
+const hookRepoJson = `"repository": {"id": 3, "name": "cli", "full_name": "org/cli", "owner": {"login": "org"}},
+	"sender": {"id": 1, "login": "alice"}`
+
+const hookPrJson = `"pull_request": {
+		"id": 12, "number": 7, "title": "Add inbox", "state": "open", "merged": false,
+		"url": "https://git.example.com/org/cli/pulls/7",
+		"html_url": "https://git.example.com/org/cli/pulls/7",
+		"user": {"login": "alice"},
+		"head": {"label": "inbox", "ref": "inbox", "sha": "1f0e", "repo_id": 3, "repo": {"id": 3, "full_name": "org/cli"}},
+		"base": {"label": "master", "ref": "master", "sha": "9a8b", "repo_id": 3, "repo": {"id": 3, "full_name": "org/cli"}}
+	}`
+
+var hookCases = []struct {
+	name      string
+	event     string
+	eventType string
+	body      string
+	want      string
+}{
+	{
+		name:      "review request",
+		event:     "pull_request",
+		eventType: "pull_request_review_request",
+		body: `{"action": "review_requested", "number": 7, ` + hookPrJson + `,
+			"requested_reviewer": {"id": 2, "login": "bob"}, ` + hookRepoJson + `}`,
+		want: "alice requested review from bob on PR [org/cli#7 Add inbox](https://git.example.com/org/cli/pulls/7)",
+	},
+	{
+		name:      "approved review",
+		event:     "pull_request_approved",
+		eventType: "pull_request_review_approved",
+		body: `{"action": "reviewed", "number": 7, ` + hookPrJson + `,
+			"review": {"type": "pull_request_review_approved", "content": "LGTM"}, ` + hookRepoJson + `}`,
+		want: "alice approved PR [org/cli#7 Add inbox](https://git.example.com/org/cli/pulls/7)\n> LGTM",
+	},
+	{
+		name:      "rejected review",
+		event:     "pull_request_rejected",
+		eventType: "pull_request_review_rejected",
+		body: `{"action": "reviewed", "number": 7, ` + hookPrJson + `,
+			"review": {"type": "pull_request_review_rejected", "content": "needs tests\nsee below"}, ` + hookRepoJson + `}`,
+		want: "alice requested changes on PR [org/cli#7 Add inbox](https://git.example.com/org/cli/pulls/7)\n> needs tests",
+	},
+	{
+		name:      "comment on pull request",
+		event:     "issue_comment",
+		eventType: "pull_request_comment",
+		body: `{"action": "created",
+			"issue": {"id": 12, "number": 7, "title": "Add inbox", "state": "open",
+				"html_url": "https://git.example.com/org/cli/pulls/7",
+				"pull_request": {"merged": false, "merged_at": null}},
+			"comment": {"id": 40, "html_url": "https://git.example.com/org/cli/pulls/7#issuecomment-40",
+				"user": {"login": "alice"}, "body": "rebased on master"},
+			"is_pull": true, ` + hookRepoJson + `}`,
+		want: "alice commented on PR [org/cli#7 Add inbox](https://git.example.com/org/cli/pulls/7#issuecomment-40)\n> rebased on master",
+	},
+	{
+		name:      "comment on issue",
+		event:     "issue_comment",
+		eventType: "issue_comment",
+		body: `{"action": "created",
+			"issue": {"id": 13, "number": 8, "title": "Crash on start", "state": "open",
+				"html_url": "https://git.example.com/org/cli/issues/8", "pull_request": null},
+			"comment": {"id": 41, "html_url": "https://git.example.com/org/cli/issues/8#issuecomment-41",
+				"user": {"login": "alice"}, "body": "cant reproduce"},
+			"is_pull": false, ` + hookRepoJson + `}`,
+		want: "alice commented on issue [org/cli#8 Crash on start](https://git.example.com/org/cli/issues/8#issuecomment-41)\n> cant reproduce",
+	},
+	{
+		name:      "merged pull request",
+		event:     "pull_request",
+		eventType: "pull_request",
+		body:      `{"action": "closed", "number": 7, ` + strings.Replace(hookPrJson, `"merged": false`, `"merged": true`, 1) + `, ` + hookRepoJson + `}`,
+		want:      "alice merged PR [org/cli#7 Add inbox](https://git.example.com/org/cli/pulls/7) (*inbox* -> *master*)",
+	},
+	{
+		name:      "synchronized pull request is not posted",
+		event:     "pull_request",
+		eventType: "pull_request_sync",
+		body:      `{"action": "synchronized", "number": 7, ` + hookPrJson + `, ` + hookRepoJson + `}`,
+	},
+}
+
+func TestHookHandler(t *testing.T) {
+	for _, c := range hookCases {
+		t.Run(c.name, func(t *testing.T) {
+			n := &recordNotifier{}
+			target := common.NotifyTarget{Type: common.NotifySlack, Url: "http://slack"}
+			h := &hookHandler{
+				config:  &common.Config{Notify: []common.NotifyTarget{target}},
+				targets: []notifyTarget{{NotifyTarget: target, Notifier: n}},
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(c.body))
+			req.Header.Set("X-Gitea-Event", c.event)
+			req.Header.Set("X-Gitea-Event-Type", c.eventType)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusNoContent {
+				t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+			}
+			if c.want == "" {
+				if len(n.msgs) != 0 {
+					t.Fatalf("expected nothing posted, got %q", n.msgs)
+				}
+				return
+			}
+			if len(n.msgs) != 1 || n.msgs[0] != c.want {
+				t.Fatalf("expected %q, got %q", c.want, n.msgs)
+			}
+		})
+	}
+}
+
+func TestRouteHookEvent(t *testing.T) {
+	c := &common.Config{
+		Rocketchat: common.Rocketchat{Enabled: true, DefaultNotifyChannel: "general"},
+		Notify: []common.NotifyTarget{
+			{Type: common.NotifyMatrix, Url: "http://matrix", Token: "t"},
+			{Type: common.NotifyTeams, Url: "http://teams"},
+		},
+		HookServer: common.HookServer{
+			Routes: []common.HookRoute{
+				{Events: []string{"pull_request"}, Channel: "rocketchat=dev,matrix=#dev:matrix.org"},
+				{Events: []string{"pull_request"}, Repos: []string{"org/*"}, Channel: "rocketchat=dev"},
+				{Events: []string{"push"}, Channel: "dev"},
+			},
+		},
+	}
+
+	routes, err := routeHookEvent(c, "pull_request", "org/cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"rocketchat": {"dev"},
+		"matrix":     {"#dev:matrix.org", ""},
+		"teams":      {""},
+	}
+	for k, v := range want {
+		if strings.Join(routes[k], "|") != strings.Join(v, "|") {
+			t.Errorf("%s: expected %q, got %q", k, v, routes[k])
+		}
+	}
+
+	// plain channel cant be sent to several kinds of chats
+	if _, err := routeHookEvent(c, "push", "org/cli"); err == nil {
+		t.Error("expected error for channel without target")
+	}
+}
@@ -0,0 +1,594 @@
+package cmd
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"os"
+	"strings"
+)
+
+/*
+stacks are chains of branches where every branch is based on the previous one.
+parent of every layer is kept in git config as branch.<name>.stack-parent,
+bottom layer points to the branch which the whole stack is merged into.
+while stack sync is in progress branch.<name>.stack-upstream keeps commit
+after which commits of the layer start, so sync can be resumed after conflicts.
+*/
+
+const (
+	stackParentKey   = "stack-parent"
+	stackUpstreamKey = "stack-upstream"
+)
+
+const (
+	stackNavBegin = "<!-- gitea-cli stack -->"
+	stackNavEnd   = "<!-- /gitea-cli stack -->"
+)
+
+type stack struct {
+	// branch which bottom layer targets
+	Base   string
+	Layers []string
+}
+
+func getStackParent(branch string) string {
+	p, _ := gitOutput("config", "branch."+branch+"."+stackParentKey)
+	return p
+}
+
+func setStackParent(branch, parent string) error {
+	return gitRun("config", "branch."+branch+"."+stackParentKey, parent)
+}
+
+func unsetStackParent(branch string) error {
+	return gitRun("config", "--unset", "branch."+branch+"."+stackParentKey)
+}
+
+func getStackUpstream(branch string) string {
+	u, _ := gitOutput("config", "branch."+branch+"."+stackUpstreamKey)
+	return u
+}
+
+func setStackUpstream(branch, upstream string) error {
+	return gitRun("config", "branch."+branch+"."+stackUpstreamKey, upstream)
+}
+
+func unsetStackUpstream(branch string) error {
+	return gitRun("config", "--unset", "branch."+branch+"."+stackUpstreamKey)
+}
+
+// child -> parent for every recorded layer
+func getStackParents() (map[string]string, error) {
+	o, err := gitOutput("config", "--get-regexp", `^branch\..*\.`+stackParentKey+`$`)
+	res := make(map[string]string)
+	if err != nil || o == "" {
+		// git exits with 1 when nothing matches
+		return res, nil
+	}
+	for _, l := range strings.Split(o, "\n") {
+		f := strings.SplitN(l, " ", 2)
+		if len(f) != 2 {
+			continue
+		}
+		b := strings.TrimSuffix(strings.TrimPrefix(f[0], "branch."), "."+stackParentKey)
+		res[b] = f[1]
+	}
+	return res, nil
+}
+
+// find stack which contains branch
+func getStack(branch string) (*stack, error) {
+	parents, err := getStackParents()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, e := parents[branch]; !e {
+		return nil, fmt.Errorf("branch %s is not part of any stack, use stack set first", branch)
+	}
+
+	// walk down to the base
+	bottom := branch
+	for {
+		p := parents[bottom]
+		if _, e := parents[p]; !e {
+			break
+		}
+		bottom = p
+	}
+
+	children := make(map[string][]string)
+	for c, p := range parents {
+		children[p] = append(children[p], c)
+	}
+
+	s := &stack{
+		Base: parents[bottom],
+	}
+	for l := bottom; ; {
+		s.Layers = append(s.Layers, l)
+		c := children[l]
+		if len(c) == 0 {
+			break
+		}
+		if len(c) > 1 {
+			return nil, fmt.Errorf("stack forks at %s into %s", l, strings.Join(c, ", "))
+		}
+		l = c[0]
+	}
+
+	return s, nil
+}
+
+// parent of layer i
+func (s *stack) parent(i int) string {
+	if i == 0 {
+		return s.Base
+	}
+	return s.Layers[i-1]
+}
+
+// most recent pr for every layer
+func (s *stack) findPrs(repoCtx *gitea.RepoCtx) (map[string]gitea.PullRequest, error) {
+	prs, err := repoCtx.ListAllPR(&gitea.ListPRRequest{
+		State: "all",
+	})
+	if err != nil {
+		return nil, err
+	}
+	layers := make(map[string]bool)
+	for i := range s.Layers {
+		layers[s.Layers[i]] = true
+	}
+	res := make(map[string]gitea.PullRequest)
+	for i := range prs {
+		h := prs[i].Head.Ref
+		if !layers[h] {
+			continue
+		}
+		if p, e := res[h]; !e || prs[i].Number > p.Number {
+			res[h] = prs[i]
+		}
+	}
+	return res, nil
+}
+
+func prStateStr(pr *gitea.PullRequest) string {
+	if pr.Merged {
+		return "merged"
+	}
+	return string(pr.State)
+}
+
+// markdown table linking all prs of the stack, current layer is marked
+func (s *stack) navTable(prs map[string]gitea.PullRequest, current string) string {
+	sb := strings.Builder{}
+	sb.WriteString(stackNavBegin + "\n")
+	sb.WriteString("**Stack** (bottom first)\n\n")
+	sb.WriteString("| | PR | branch | base | state |\n")
+	sb.WriteString("|---|---|---|---|---|\n")
+	for i := range s.Layers {
+		l := s.Layers[i]
+		mark := ""
+		if l == current {
+			mark = "**>**"
+		}
+		pr, e := prs[l]
+		if !e {
+			sb.WriteString(fmt.Sprintf("| %s | | `%s` | `%s` | no PR |\n", mark, l, s.parent(i)))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("| %s | #%d | `%s` | `%s` | %s |\n", mark, pr.Number, l, s.parent(i), prStateStr(&pr)))
+	}
+	sb.WriteString(stackNavEnd)
+	return sb.String()
+}
+
+// replace navigation table within body or append it
+func setStackNav(body, nav string) string {
+	b := strings.Index(body, stackNavBegin)
+	e := strings.Index(body, stackNavEnd)
+	if b >= 0 && e > b {
+		return body[:b] + nav + body[e+len(stackNavEnd):]
+	}
+	if body == "" {
+		return nav
+	}
+	return body + "\n\n" + nav
+}
+
+// update navigation table in every open pr of the stack
+func (s *stack) updateNav(repoCtx *gitea.RepoCtx, prs map[string]gitea.PullRequest) error {
+	for _, l := range s.Layers {
+		pr, e := prs[l]
+		if !e || pr.State != gitea.Open {
+			continue
+		}
+		body := setStackNav(pr.Body, s.navTable(prs, l))
+		if body == pr.Body {
+			continue
+		}
+		if err := repoCtx.UpdatePR(&gitea.UpdatePrRequest{
+			Index: pr.Number,
+			Opt: gitea.EditPullRequestOption{
+				Body: &body,
+			},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setStackOpts(c *common.Config) []CmdOpt {
+	def := ""
+	if c != nil {
+		def = c.Gitea.DefaultBaseForPR
+	}
+	return []CmdOpt{
+		addOptWithDefaultVal("layers     ", "comma separated branches, bottom first", []string{"l", "layers"}, ""),
+		addOptWithDefaultVal("base branch", "target of the bottom layer", []string{"b", "base"}, def),
+	}
+}
+
+func (ctx *CmdCtx) SetStackCommand() error {
+	opts := setStackOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	base := opts[1].Val.Str
+
+	layers := splitList(opts[0].Val.Str)
+	if len(layers) == 0 {
+		return fmt.Errorf("no layers provided")
+	}
+
+	for i := range layers {
+		if getLocalBranchSha(layers[i]) == "" {
+			return fmt.Errorf("branch %s doesnt exist locally", layers[i])
+		}
+	}
+
+	parent := base
+	for i := range layers {
+		if err := setStackParent(layers[i], parent); err != nil {
+			return err
+		}
+		parent = layers[i]
+	}
+
+	fmt.Printf("stack: %s <- %s\n", base, strings.Join(layers, " <- "))
+
+	return nil
+}
+
+func stackOpts(c *common.Config) []CmdOpt {
+	opts := repoInfoOpts(c)
+	// 2
+	opts = append(opts, addOptWithDefaultVal("branch     ", "any layer of the stack", []string{"s", "stack"}, getBranch()))
+	// 3
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"d", "dry"},
+			Label:    "dry run [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+	return opts
+}
+
+func (ctx *CmdCtx) ShowStackCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := stackOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	s, err := getStack(opts[2].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	prs, err := s.findPrs(repoCtx)
+	if err != nil {
+		return err
+	}
+
+	for i, l := range s.Layers {
+		pr, e := prs[l]
+		if !e {
+			fmt.Printf("%s->%s no pr\n", l, s.parent(i))
+			continue
+		}
+		fmt.Printf("%s->%s index=%d, state=%s, title=%s, url=%s\n",
+			l, s.parent(i), pr.Number, prStateStr(&pr), pr.Title, pr.Url)
+	}
+
+	return nil
+}
+
+func newStackPrOpts(c *common.Config) []CmdOpt {
+	opts := stackOpts(c)
+	// 4
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"w", "wip"},
+			Label:    "work in progress [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+	// 5
+	opts = append(opts, CmdOpt{
+		Spec: CmdOptSpec{
+			ArgFlags: []string{"p", "push"},
+			Label:    "push layers without asking if theyre missing or behind on remote [default: false]",
+			NoPrompt: true,
+			IsBool:   true,
+		},
+	})
+	return opts
+}
+
+func (ctx *CmdCtx) NewStackPrCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := newStackPrOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	dry := opts[3].Val.Bool
+	wip := opts[4].Val.Bool
+	push := opts[5].Val.Bool
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	s, err := getStack(opts[2].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	prs, err := s.findPrs(repoCtx)
+	if err != nil {
+		return err
+	}
+
+	// layers above merged one would target it and merged work would be proposed again
+	for _, l := range s.Layers {
+		if pr, e := prs[l]; e && pr.Merged {
+			return fmt.Errorf("pr #%d of %s is merged, run stack sync first", pr.Number, l)
+		}
+	}
+
+	for i, l := range s.Layers {
+		if pr, e := prs[l]; e && pr.State == gitea.Open {
+			continue
+		}
+
+		title := l
+		if wip {
+			title = "WIP: " + title
+		}
+
+		fmt.Printf("Creating pr for %s/%s %s->%s with title: '%s'\n", repoCtx.Owner, repoCtx.Repo, l, s.parent(i), title)
+
+		if err := ensureBranchPushed(l, push, dry); err != nil {
+			return err
+		}
+
+		if dry {
+			continue
+		}
+
+		pr, err := repoCtx.CreatePR(&gitea.CreatePRRequest{
+			Opt: gitea.CreatePullRequestOption{
+				Base:  s.parent(i),
+				Head:  l,
+				Title: title,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		prs[l] = *pr
+
+		fmt.Printf("%s\n", pr.Url)
+	}
+
+	if dry {
+		return nil
+	}
+
+	return s.updateNav(repoCtx, prs)
+}
+
+/*
+drop merged layers from the stack, retarget prs above them,
+rebase remaining layers onto their new parents and push them.
+*/
+func (ctx *CmdCtx) SyncStackCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := stackOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	dry := opts[3].Val.Bool
+
+	repoCtx := ctx.newRepoCtx(opts[0].Val.Str, opts[1].Val.Str)
+	if err := repoCtx.Validate(); err != nil {
+		return err
+	}
+
+	s, err := getStack(opts[2].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	prs, err := s.findPrs(repoCtx)
+	if err != nil {
+		return err
+	}
+
+	remote := getBranchRemote(s.Layers[0])
+	if err := gitRun("fetch", remote); err != nil {
+		return err
+	}
+
+	// shas before rebasing, needed to cut off commits of old parents
+	oldSha := make(map[string]string)
+	for _, l := range s.Layers {
+		oldSha[l] = getLocalBranchSha(l)
+	}
+
+	kept := &stack{Base: s.Base}
+	var merged []string
+	for _, l := range s.Layers {
+		if pr, e := prs[l]; e && pr.Merged {
+			fmt.Printf("%s was merged, removing from stack\n", l)
+			merged = append(merged, l)
+			continue
+		}
+		kept.Layers = append(kept.Layers, l)
+	}
+
+	if len(kept.Layers) == 0 {
+		fmt.Println("whole stack was merged")
+		if dry {
+			return nil
+		}
+		for _, l := range merged {
+			if err := unsetStackParent(l); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	/*
+		git config is changed only after every layer was rebased,
+		so when sync stops on conflict the next run sees the same stack.
+		upstreams are recorded before first rebase and survive until then.
+	*/
+	upstreams := make(map[string]string)
+	for _, l := range kept.Layers {
+		u := getStackUpstream(l)
+		if u == "" {
+			oldParent := getStackParent(l)
+			u = oldParent
+			if sha := oldSha[oldParent]; sha != "" {
+				u = sha
+			} else if sha := getLocalBranchSha(oldParent); sha != "" {
+				u = sha
+			}
+			if !dry {
+				if err := setStackUpstream(l, u); err != nil {
+					return err
+				}
+			}
+		}
+		upstreams[l] = u
+	}
+
+	cur := getBranch()
+	rebased := make(map[string]bool)
+
+	for i, l := range kept.Layers {
+		oldParent := getStackParent(l)
+		newParent := kept.parent(i)
+
+		if oldParent == newParent && !rebased[oldParent] && upstreams[l] == getLocalBranchSha(newParent) {
+			continue
+		}
+
+		if oldParent != newParent {
+			fmt.Printf("retargeting %s from %s to %s\n", l, oldParent, newParent)
+			if pr, e := prs[l]; e && pr.State == gitea.Open && pr.Base.Ref != newParent && !dry {
+				if err := repoCtx.UpdatePR(&gitea.UpdatePrRequest{
+					Index: pr.Number,
+					Opt: gitea.EditPullRequestOption{
+						Base: newParent,
+					},
+				}); err != nil {
+					return err
+				}
+				pr.Base.Ref = newParent
+				prs[l] = pr
+			}
+		}
+
+		onto := newParent
+		if i == 0 {
+			onto = remote + "/" + newParent
+		}
+
+		fmt.Printf("rebasing %s onto %s\n", l, onto)
+		rebased[l] = true
+		if dry {
+			continue
+		}
+
+		if err := gitRun("rebase", "--onto", onto, upstreams[l], l); err != nil {
+			return fmt.Errorf("%v\nresolve conflicts, finish the rebase and run stack sync again", err)
+		}
+		if err := gitRun("push", "--force-with-lease", getBranchRemote(l), l); err != nil {
+			return err
+		}
+		// rebased layer starts right after onto, rerun only replays its own commits
+		ontoSha, err := gitOutput("rev-parse", onto)
+		if err != nil {
+			return err
+		}
+		if err := setStackUpstream(l, ontoSha); err != nil {
+			return err
+		}
+	}
+
+	if dry {
+		return nil
+	}
+
+	for i, l := range kept.Layers {
+		if getStackParent(l) != kept.parent(i) {
+			if err := setStackParent(l, kept.parent(i)); err != nil {
+				return err
+			}
+		}
+		if err := unsetStackUpstream(l); err != nil {
+			return err
+		}
+	}
+	for _, l := range merged {
+		if err := unsetStackParent(l); err != nil {
+			return err
+		}
+	}
+
+	if cur != "" && cur != getBranch() {
+		if err := gitRun("checkout", cur); err != nil {
+			return err
+		}
+	}
+
+	return kept.updateNav(repoCtx, prs)
+}
@@ -0,0 +1,380 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/gitea"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	watchOutputTerminal = "terminal"
+	watchOutputDesktop  = "desktop"
+	watchOutputChat     = "chat"
+
+	// closed prs fetched per repository on every poll
+	watchClosedLimit = 50
+)
+
+type watchEvent struct {
+	Repo string
+	// new pr, merged, review requested, new comments or notification type
+	Kind  string
+	Title string
+	Url   string
+}
+
+func (e *watchEvent) String() string {
+	return fmt.Sprintf("[%s] %s: %s %s", e.Repo, e.Kind, e.Title, e.Url)
+}
+
+type watchPrState struct {
+	Merged          bool `json:"merged"`
+	Comments        int  `json:"comments"`
+	ReviewRequested bool `json:"review_requested"`
+}
+
+// what was already reported, persisted between runs
+type watchState struct {
+	// thread id -> updated_at of unread notifications
+	Notifications map[string]string `json:"notifications"`
+	// owner/repo#index -> last seen pr
+	Prs map[string]watchPrState `json:"prs"`
+}
+
+// false is returned when there was no state yet
+func loadWatchState(path string) (*watchState, bool, error) {
+	st := &watchState{
+		Notifications: make(map[string]string),
+		Prs:           make(map[string]watchPrState),
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return st, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if err := json.Unmarshal(b, st); err != nil {
+		return nil, false, fmt.Errorf("%s: %v", path, err)
+	}
+	if st.Notifications == nil {
+		st.Notifications = make(map[string]string)
+	}
+	if st.Prs == nil {
+		st.Prs = make(map[string]watchPrState)
+	}
+	return st, true, nil
+}
+
+func (st *watchState) save(path string) error {
+	b, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	// dont leave truncated state behind when killed while writing
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func pollNotifications(gctx *gitea.Ctx, st *watchState) ([]watchEvent, error) {
+	threads, err := gctx.ListAllNotifications(&gitea.ListNotificationsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	var res []watchEvent
+	seen := make(map[string]string, len(threads))
+	for _, t := range threads {
+		id := strconv.Itoa(t.ID)
+		seen[id] = t.UpdatedAt
+		if st.Notifications[id] == t.UpdatedAt {
+			continue
+		}
+		repo := ""
+		if t.Repository != nil {
+			repo = t.Repository.FullName
+		}
+		url := t.Subject.LatestCommentHtmlUrl
+		if url == "" {
+			url = t.Subject.HtmlUrl
+		}
+		res = append(res, watchEvent{
+			Repo:  repo,
+			Kind:  strings.ToLower(t.Subject.Type),
+			Title: t.Subject.Title,
+			Url:   url,
+		})
+	}
+	// read threads are forgotten so they are reported again when they get new activity
+	st.Notifications = seen
+	return res, nil
+}
+
+func pollPrs(repoCtx *gitea.RepoCtx, me string, st *watchState) ([]watchEvent, error) {
+	open, err := repoCtx.ListAllPR(&gitea.ListPRRequest{
+		State: string(gitea.Open),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// most recently updated closed prs are enough to notice merges
+	closed, err := repoCtx.ListPR(&gitea.ListPRRequest{
+		State: string(gitea.Closed),
+		Sort:  "recentupdate",
+		Limit: watchClosedLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	repo := repoCtx.Owner + "/" + repoCtx.Repo
+	prefix := repo + "#"
+	current := make(map[string]bool)
+	var res []watchEvent
+
+	for _, pr := range append(open, closed...) {
+		key := prefix + strconv.Itoa(pr.Number)
+		current[key] = true
+		prev, seen := st.Prs[key]
+
+		ev := func(kind string) {
+			res = append(res, watchEvent{
+				Repo:  repo,
+				Kind:  kind,
+				Title: fmt.Sprintf("#%d %s", pr.Number, pr.Title),
+				Url:   pr.Url,
+			})
+		}
+
+		requested := false
+		for _, u := range pr.RequestedReviewers {
+			if u.Login == me {
+				requested = true
+			}
+		}
+
+		switch {
+		case !seen && pr.State == gitea.Open && pr.User.Login != me:
+			ev("new pr by " + pr.User.Login)
+		case seen && pr.Merged && !prev.Merged:
+			ev("merged")
+		}
+		if seen && pr.Comments > prev.Comments {
+			ev(fmt.Sprintf("%d new comment(s)", pr.Comments-prev.Comments))
+		}
+		if requested && !prev.ReviewRequested && pr.State == gitea.Open {
+			ev("review requested")
+		}
+
+		st.Prs[key] = watchPrState{
+			Merged:          pr.Merged,
+			Comments:        pr.Comments,
+			ReviewRequested: requested,
+		}
+	}
+
+	for key := range st.Prs {
+		if strings.HasPrefix(key, prefix) && !current[key] {
+			delete(st.Prs, key)
+		}
+	}
+
+	return res, nil
+}
+
+type watchOutput func(ev *watchEvent) error
+
+func newWatchOutputs(ctx *CmdCtx, names []string, channel string) ([]watchOutput, error) {
+	var res []watchOutput
+	for _, n := range names {
+		switch n {
+		case watchOutputTerminal:
+			res = append(res, func(ev *watchEvent) error {
+				fmt.Printf("%s %s\n", time.Now().Format("15:04:05"), ev)
+				return nil
+			})
+		case watchOutputDesktop:
+			bin, err := exec.LookPath("notify-send")
+			if err != nil {
+				return nil, fmt.Errorf("desktop output needs notify-send: %v", err)
+			}
+			res = append(res, func(ev *watchEvent) error {
+				return exec.Command(bin, "-a", "gitea-cli", ev.Repo, ev.Kind+": "+ev.Title).Run()
+			})
+		case watchOutputChat:
+			targets, err := newNotifiers(ctx.Config)
+			if err != nil {
+				return nil, err
+			}
+			if len(targets) == 0 {
+				return nil, fmt.Errorf("chat output needs notify targets or rocketchat in config")
+			}
+			channels, err := parseNotifyChannels(ctx.Config, channel)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, func(ev *watchEvent) error {
+				return postNotification(targets, channels,
+					fmt.Sprintf("[%s] %s: [%s](%s)", ev.Repo, ev.Kind, ev.Title, ev.Url), false)
+			})
+		default:
+			return nil, fmt.Errorf("invalid output %s, expected %s, %s or %s",
+				n, watchOutputTerminal, watchOutputDesktop, watchOutputChat)
+		}
+	}
+	return res, nil
+}
+
+func watchOpts(c *common.Config) []CmdOpt {
+	return []CmdOpt{
+		// 0
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"repos"},
+				Label:    "comma separated owner/repo globs whose prs are polled",
+				Optional: true,
+				NoPrompt: true,
+			},
+		},
+		// 1
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"nonotifications"},
+				Label:    "dont poll notifications of current user [default: false]",
+				NoPrompt: true,
+				IsBool:   true,
+			},
+		},
+		// 2
+		addOptWithDefaultVal("interval   ", "", []string{"interval"}, "1m"),
+		// 3
+		addOptWithDefaultVal("state file ", "", []string{"state"}, ".gitea-watch.json"),
+		// 4
+		addOptWithDefaultVal("outputs    ", "", []string{"output"}, watchOutputTerminal),
+		// 5
+		{
+			Spec: CmdOptSpec{
+				ArgFlags: []string{"notify"},
+				Label:    "chat channels as target=channel pairs [default: channel of each notify target]",
+				Optional: true,
+				NoPrompt: true,
+			},
+		},
+	}
+}
+
+// poll notifications and prs until SIGINT or SIGTERM
+func (ctx *CmdCtx) WatchCommand() error {
+	if err := ctx.ValidateConfig(true); err != nil {
+		return err
+	}
+
+	opts := watchOpts(ctx.Config)
+	if err := GetOpts(os.Args[1:], opts); err != nil {
+		return err
+	}
+
+	globs := splitList(opts[0].Val.Str)
+	notifications := !opts[1].Val.Bool
+	statePath := opts[3].Val.Str
+
+	interval, err := time.ParseDuration(opts[2].Val.Str)
+	if err != nil || interval <= 0 {
+		return fmt.Errorf("invalid interval: %s", opts[2].Val.Str)
+	}
+
+	if !notifications && len(globs) == 0 {
+		return fmt.Errorf("nothing to watch, give --repos or drop --nonotifications")
+	}
+
+	outputs, err := newWatchOutputs(ctx, splitList(opts[4].Val.Str), opts[5].Val.Str)
+	if err != nil {
+		return err
+	}
+
+	gctx := ctx.newGiteaCtx()
+	if err := gctx.Validate(); err != nil {
+		return err
+	}
+
+	me, err := gctx.GetCurrentUser()
+	if err != nil {
+		return err
+	}
+
+	var repos []gitea.Repository
+	if len(globs) > 0 {
+		if repos, err = expandRepoGlobs(gctx, globs, nil); err != nil {
+			return err
+		}
+	}
+
+	st, existed, err := loadWatchState(statePath)
+	if err != nil {
+		return err
+	}
+
+	// replace default handler which exits right away
+	stop := make(chan os.Signal, 1)
+	signal.Reset(os.Interrupt, syscall.SIGTERM)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	fmt.Printf("watching as %s: notifications=%t, repositories=%d, every %s\n", me.Login, notifications, len(repos), interval)
+
+	for {
+		var events []watchEvent
+
+		if notifications {
+			ev, err := pollNotifications(gctx, st)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "notifications: %v\n", err)
+			}
+			events = append(events, ev...)
+		}
+
+		for i := range repos {
+			ev, err := pollPrs(gctx.RepoCtx(repos[i].Owner.Login, repos[i].Name), me.Login, st)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", repos[i].FullName, err)
+			}
+			events = append(events, ev...)
+		}
+
+		// first run only records what is there already
+		if !existed {
+			fmt.Printf("initialized %s, only changes from now on are reported\n", statePath)
+			existed = true
+			events = nil
+		}
+
+		for i := range events {
+			for _, out := range outputs {
+				if err := out(&events[i]); err != nil {
+					fmt.Fprintf(os.Stderr, "output: %v\n", err)
+				}
+			}
+		}
+
+		if err := st.save(statePath); err != nil {
+			return err
+		}
+
+		select {
+		case s := <-stop:
+			fmt.Printf("received %s, state saved to %s\n", s, statePath)
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
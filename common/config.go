@@ -2,6 +2,7 @@ package common
 
 import (
 	"fmt"
+	"os"
 )
 
 type RemoteInfo struct {
@@ -38,6 +39,9 @@ type GiteaConfig struct {
 	// this branch will be used as default base for mr's
 	DefaultBaseForPR string `yaml:"default_base_for_pr"`
 
+	// protocol used to clone repositories: https or ssh, https when empty
+	CloneProtocol string `yaml:"clone_protocol"`
+
 	RemoteInfo `yaml:"remote_info"`
 }
 
@@ -86,9 +90,117 @@ func (c *Rocketchat) Validate(cred bool) error {
 	return c.RemoteInfo.Validate()
 }
 
+const (
+	NotifyRocketchat = "rocketchat"
+	NotifySlack      = "slack"
+	NotifyMattermost = "mattermost"
+	NotifyMatrix     = "matrix"
+	NotifyTeams      = "teams"
+)
+
+// chat where pr merges and other events are posted
+type NotifyTarget struct {
+	// rocketchat, slack, mattermost, matrix or teams
+	Type string `yaml:"type"`
+	// picks target in channel lists like --notify, type when empty.
+	// needed only when there are several targets of the same type
+	Name string `yaml:"name"`
+	// incoming webhook url, homeserver url for matrix, unused by rocketchat
+	// which takes credentials from rocketchat section.
+	// may reference environment variables
+	Url string `yaml:"url"`
+	// matrix access token, may reference environment variables
+	Token string `yaml:"token"`
+	// channel or matrix room used when command doesnt give one,
+	// webhook default when empty
+	Channel string `yaml:"channel"`
+	// text put before pr notifications, can be empty
+	Header string `yaml:"header"`
+}
+
+func (c *NotifyTarget) validationErr(msg string) error {
+	return fmt.Errorf("Validate NotifyTarget %s: %s", c.Key(), msg)
+}
+
+func (c *NotifyTarget) Key() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Type
+}
+
+// channel is required for targets which dont have webhook bound to one
+func (c *NotifyTarget) NeedsChannel() bool {
+	return c.Type == NotifyRocketchat || c.Type == NotifyMatrix
+}
+
+// url and token are checked after expanding environment variables, unset ones are invalid
+func (c *NotifyTarget) Validate() error {
+	switch c.Type {
+	case NotifyRocketchat:
+		return nil
+	case NotifySlack, NotifyMattermost, NotifyTeams:
+	case NotifyMatrix:
+		if os.ExpandEnv(c.Token) == "" {
+			return c.validationErr("invalid token")
+		}
+	default:
+		return c.validationErr("invalid type, expected rocketchat, slack, mattermost, matrix or teams")
+	}
+	if os.ExpandEnv(c.Url) == "" {
+		return c.validationErr("invalid url")
+	}
+	return nil
+}
+
+type ChangelogSection struct {
+	Title  string   `yaml:"title"`
+	Labels []string `yaml:"labels"`
+	// version part bumped by prs in this section: major, minor or patch
+	Bump string `yaml:"bump"`
+}
+
+type Changelog struct {
+	// rendered in this order, pr goes to the first section matching one of its labels
+	Sections []ChangelogSection `yaml:"sections"`
+	// title of section for prs not matching any other, such prs are skipped when empty
+	Other string `yaml:"other"`
+	// go template used instead of default markdown, can be empty
+	Template string `yaml:"template"`
+	// version part bumped by prs not matching any section with bump set
+	DefaultBump string `yaml:"default_bump"`
+}
+
+type HookRoute struct {
+	// gitea event types, eg. pull_request, pull_request_review_request,
+	// pull_request_comment, issues, issue_comment, push, release, status; all when empty
+	Events []string `yaml:"events"`
+	// owner/repo globs; all when empty
+	Repos []string `yaml:"repos"`
+	// channels per notify target in --notify form, eg. rocketchat=dev,matrix=#dev:matrix.org;
+	// plain channel is enough when there is single target.
+	// targets not listed use their default channel
+	Channel string `yaml:"channel"`
+}
+
+// used by serve hooks
+type HookServer struct {
+	// address to listen on, :8080 when empty
+	Listen string `yaml:"listen"`
+	// secret configured in gitea webhooks, may reference environment variables,
+	// server refuses to start without it unless --insecure is given
+	Secret string `yaml:"secret"`
+	// event is posted to channel of every matching route, default channels when none matches
+	Routes []HookRoute `yaml:"routes"`
+}
+
 type Config struct {
 	Gitea      GiteaConfig
 	Rocketchat Rocketchat
+	Changelog  Changelog
+	HookServer HookServer `yaml:"hook_server"`
+	// every target is notified, enabled rocketchat is implicitly one of them
+	Notify []NotifyTarget `yaml:"notify"`
 }
 
 func (c *Config) validationErr(msg string) error {
@@ -100,7 +212,54 @@ func (c *Config) Validate(cred bool) error {
 		return err
 	}
 	if c.Rocketchat.Enabled {
-		return c.Rocketchat.Validate(cred)
+		if err := c.Rocketchat.Validate(cred); err != nil {
+			return err
+		}
+	}
+	keys := make(map[string]bool)
+	for _, t := range c.NotifyTargets() {
+		if keys[t.Key()] {
+			return c.validationErr(fmt.Sprintf("duplicate notify target %s, give them distinct names", t.Key()))
+		}
+		keys[t.Key()] = true
+	}
+	for i := range c.Notify {
+		if c.Notify[i].Type == NotifyRocketchat && !c.Rocketchat.Enabled {
+			return c.validationErr("rocketchat notify target needs rocketchat enabled")
+		}
+		if err := c.Notify[i].Validate(); err != nil {
+			return err
+		}
 	}
 	return nil
 }
+
+/*
+notify targets with enabled rocketchat added when its not listed,
+rocketchat targets get default channel and header from rocketchat section
+*/
+func (c *Config) NotifyTargets() []NotifyTarget {
+	res := make([]NotifyTarget, 0, len(c.Notify)+1)
+	listed := false
+	for _, t := range c.Notify {
+		if t.Type == NotifyRocketchat {
+			listed = true
+		}
+		res = append(res, t)
+	}
+	if c.Rocketchat.Enabled && !listed {
+		res = append([]NotifyTarget{{Type: NotifyRocketchat}}, res...)
+	}
+	for i := range res {
+		if res[i].Type != NotifyRocketchat {
+			continue
+		}
+		if res[i].Channel == "" {
+			res[i].Channel = c.Rocketchat.DefaultNotifyChannel
+		}
+		if res[i].Header == "" {
+			res[i].Header = c.Rocketchat.DefaultHeader
+		}
+	}
+	return res
+}
@@ -7,9 +7,27 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 )
 
+// returned by HttpRequest when server responds with unexpected status code
+type StatusError struct {
+	Expected int
+	Got      int
+	Body     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("invalid status code, expected %d got %d;\n%s", e.Expected, e.Got, e.Body)
+}
+
+// check if err is StatusError with given status code
+func IsStatus(err error, code int) bool {
+	var serr *StatusError
+	return errors.As(err, &serr) && serr.Got == code
+}
+
 /*
 
 m: http method
@@ -22,7 +40,6 @@ ec: expected status code
 func HttpRequest(m, u string, req, res interface{}, hdr http.Header, ec int) error {
 
 	var reqr io.Reader
-	var err error
 
 	if req != nil {
 		rb, err := json.Marshal(req)
@@ -32,13 +49,48 @@ func HttpRequest(m, u string, req, res interface{}, hdr http.Header, ec int) err
 		reqr = bytes.NewReader(rb)
 	}
 
-	httpReq, err := http.NewRequest(m, u, reqr)
+	jsonHdr := make(http.Header)
+	jsonHdr.Set("Content-Type", "application/json; charset=utf-8")
+	jsonHdr.Set("Accept", "application/json; charset=utf-8")
+	for x := range hdr {
+		for y := range hdr[x] {
+			jsonHdr.Add(x, hdr[x][y])
+		}
+	}
+
+	bt, err := HttpRequestRaw(m, u, reqr, jsonHdr, ec)
 	if err != nil {
 		return err
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json; charset=utf-8")
-	httpReq.Header.Set("Accept", "application/json; charset=utf-8")
+	if res == nil {
+		return nil
+	}
+
+	//fmt.Println(string(bt))
+	if err := json.Unmarshal(bt, res); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+/*
+same as HttpRequest but body is sent and returned as is.
+
+m: http method
+u: http url
+body: request body, may be nil
+hdr: headers to add, including content-type
+ec: expected status code
+*/
+func HttpRequestRaw(m, u string, body io.Reader, hdr http.Header, ec int) ([]byte, error) {
+
+	httpReq, err := http.NewRequest(m, u, body)
+	if err != nil {
+		return nil, err
+	}
+
 	for x := range hdr {
 		for y := range hdr[x] {
 			httpReq.Header.Add(x, hdr[x][y])
@@ -47,39 +99,75 @@ func HttpRequest(m, u string, req, res interface{}, hdr http.Header, ec int) err
 
 	httpRes, err := http.DefaultClient.Do(httpReq)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	defer httpRes.Body.Close()
 
 	if httpRes.StatusCode != ec {
-		var msgFmt = "invalid status code, expected %d got %d;\n%s"
-		var msgB []byte
-		var msg string
+		serr := &StatusError{
+			Expected: ec,
+			Got:      httpRes.StatusCode,
+			Body:     "<nothing in body>",
+		}
 		if httpRes.Body != nil {
-			msgB, err = ioutil.ReadAll(httpRes.Body)
+			msgB, err := ioutil.ReadAll(httpRes.Body)
 			if err != nil {
-				return err
+				return nil, err
 			}
-			msg = fmt.Sprintf(msgFmt, ec, httpRes.StatusCode, string(msgB))
-		} else {
-			msg = fmt.Sprintf(msgFmt, ec, httpRes.StatusCode, "<nothing in body>")
+			serr.Body = string(msgB)
 		}
-		return errors.New(msg)
+		return nil, serr
 	}
 
-	if res == nil {
-		return nil
+	return ioutil.ReadAll(httpRes.Body)
+}
+
+/*
+upload file as multipart/form-data
+
+u: http url
+field: form field name
+name: file name sent to server
+r: file content
+res: response body [ptr], may be nil
+hdr: headers to add, content-type is already added
+ec: expected status code
+*/
+func HttpUpload(u, field, name string, r io.Reader, res interface{}, hdr http.Header, ec int) error {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	go func() {
+		part, err := mw.CreateFormFile(field, name)
+		if err == nil {
+			_, err = io.Copy(part, r)
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	uploadHdr := make(http.Header)
+	uploadHdr.Set("Content-Type", mw.FormDataContentType())
+	uploadHdr.Set("Accept", "application/json; charset=utf-8")
+	for x := range hdr {
+		for y := range hdr[x] {
+			uploadHdr.Add(x, hdr[x][y])
+		}
 	}
 
-	bt, err := ioutil.ReadAll(httpRes.Body)
-	//fmt.Println(string(bt))
+	bt, err := HttpRequestRaw("POST", u, pr, uploadHdr, ec)
+	// unblock writer if request failed before reading whole body
+	pr.Close()
 	if err != nil {
 		return err
 	}
-	if err := json.Unmarshal(bt, res); err != nil {
-		return err
+
+	if res == nil {
+		return nil
 	}
 
-	return nil
+	return json.Unmarshal(bt, res)
 }
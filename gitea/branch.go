@@ -17,3 +17,69 @@ func (ctx *RepoCtx) DeleteBranch(r *DeleteBranchRequest) error {
 	var u = fmt.Sprintf("%s/repos/%s/%s/branches/%s", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.Branch)
 	return common.HttpRequest(m, u, nil, nil, hdr, 204)
 }
+
+type PayloadCommit struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	Url     string `json:"url"`
+	Author  struct {
+		Name     string `json:"name"`
+		Username string `json:"username"`
+	} `json:"author"`
+	Timestamp string `json:"timestamp"`
+}
+
+type Branch struct {
+	Name      string        `json:"name"`
+	Commit    PayloadCommit `json:"commit"`
+	Protected bool          `json:"protected"`
+}
+
+type ListBranchesRequest struct {
+	// pagination, server defaults are used when 0
+	Page  int
+	Limit int
+}
+
+func (ctx *RepoCtx) ListBranches(r *ListBranchesRequest) ([]Branch, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/branches?page=%d&limit=%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.Page, r.Limit)
+	var res []Branch
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+// same as ListBranches but goes through all pages
+func (ctx *RepoCtx) ListAllBranches() ([]Branch, error) {
+	req := ListBranchesRequest{
+		Limit: pageLimit,
+	}
+	var res []Branch
+	err := forEachPage(func(page int) (int, error) {
+		req.Page = page
+		b, err := ctx.ListBranches(&req)
+		res = append(res, b...)
+		return len(b), err
+	})
+	return res, err
+}
+
+type CreateBranchRepoOption struct {
+	NewBranchName string `json:"new_branch_name"`
+	// branch, tag or commit sha
+	OldRefName string `json:"old_ref_name,omitempty"`
+}
+
+type CreateBranchRequest struct {
+	Opt CreateBranchRepoOption
+}
+
+func (ctx *RepoCtx) CreateBranch(r *CreateBranchRequest) (*Branch, error) {
+	const m = "POST"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/branches", ctx.ApiUrl, ctx.Owner, ctx.Repo)
+	var res = new(Branch)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 201)
+}
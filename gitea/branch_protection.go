@@ -0,0 +1,99 @@
+package gitea
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"net/http"
+	"net/url"
+)
+
+// used both as api payload and as yaml spec
+type BranchProtection struct {
+	// servers older than 1.17 only know branch_name
+	RuleName   string `json:"rule_name,omitempty" yaml:"rule_name,omitempty"`
+	BranchName string `json:"branch_name,omitempty" yaml:"branch_name,omitempty"`
+
+	EnablePush              bool     `json:"enable_push" yaml:"enable_push"`
+	EnablePushWhitelist     bool     `json:"enable_push_whitelist" yaml:"enable_push_whitelist"`
+	PushWhitelistUsernames  []string `json:"push_whitelist_usernames" yaml:"push_whitelist_usernames,omitempty"`
+	PushWhitelistTeams      []string `json:"push_whitelist_teams" yaml:"push_whitelist_teams,omitempty"`
+	PushWhitelistDeployKeys bool     `json:"push_whitelist_deploy_keys" yaml:"push_whitelist_deploy_keys"`
+
+	EnableMergeWhitelist    bool     `json:"enable_merge_whitelist" yaml:"enable_merge_whitelist"`
+	MergeWhitelistUsernames []string `json:"merge_whitelist_usernames" yaml:"merge_whitelist_usernames,omitempty"`
+	MergeWhitelistTeams     []string `json:"merge_whitelist_teams" yaml:"merge_whitelist_teams,omitempty"`
+
+	EnableStatusCheck   bool     `json:"enable_status_check" yaml:"enable_status_check"`
+	StatusCheckContexts []string `json:"status_check_contexts" yaml:"status_check_contexts,omitempty"`
+
+	RequiredApprovals          int      `json:"required_approvals" yaml:"required_approvals"`
+	EnableApprovalsWhitelist   bool     `json:"enable_approvals_whitelist" yaml:"enable_approvals_whitelist"`
+	ApprovalsWhitelistUsername []string `json:"approvals_whitelist_username" yaml:"approvals_whitelist_username,omitempty"`
+	ApprovalsWhitelistTeams    []string `json:"approvals_whitelist_teams" yaml:"approvals_whitelist_teams,omitempty"`
+
+	BlockOnRejectedReviews        bool `json:"block_on_rejected_reviews" yaml:"block_on_rejected_reviews"`
+	BlockOnOfficialReviewRequests bool `json:"block_on_official_review_requests" yaml:"block_on_official_review_requests"`
+	BlockOnOutdatedBranch         bool `json:"block_on_outdated_branch" yaml:"block_on_outdated_branch"`
+	DismissStaleApprovals         bool `json:"dismiss_stale_approvals" yaml:"dismiss_stale_approvals"`
+	RequireSignedCommits          bool `json:"require_signed_commits" yaml:"require_signed_commits"`
+
+	ProtectedFilePatterns   string `json:"protected_file_patterns" yaml:"protected_file_patterns,omitempty"`
+	UnprotectedFilePatterns string `json:"unprotected_file_patterns" yaml:"unprotected_file_patterns,omitempty"`
+}
+
+// name which identifies the rule in api paths
+func (p *BranchProtection) Name() string {
+	if p.RuleName != "" {
+		return p.RuleName
+	}
+	return p.BranchName
+}
+
+func (ctx *RepoCtx) ListBranchProtections() ([]BranchProtection, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/branch_protections", ctx.ApiUrl, ctx.Owner, ctx.Repo)
+	var res []BranchProtection
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+type CreateBranchProtectionRequest struct {
+	Opt BranchProtection
+}
+
+func (ctx *RepoCtx) CreateBranchProtection(r *CreateBranchProtectionRequest) (*BranchProtection, error) {
+	const m = "POST"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/branch_protections", ctx.ApiUrl, ctx.Owner, ctx.Repo)
+	var res = new(BranchProtection)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 201)
+}
+
+type EditBranchProtectionRequest struct {
+	Name string
+	// every field is sent, lists sent as null are left unchanged by gitea
+	Opt BranchProtection
+}
+
+func (ctx *RepoCtx) EditBranchProtection(r *EditBranchProtectionRequest) (*BranchProtection, error) {
+	const m = "PATCH"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/branch_protections/%s", ctx.ApiUrl, ctx.Owner, ctx.Repo, url.PathEscape(r.Name))
+	var res = new(BranchProtection)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 200)
+}
+
+type DeleteBranchProtectionRequest struct {
+	Name string
+}
+
+func (ctx *RepoCtx) DeleteBranchProtection(r *DeleteBranchProtectionRequest) error {
+	const m = "DELETE"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/branch_protections/%s", ctx.ApiUrl, ctx.Owner, ctx.Repo, url.PathEscape(r.Name))
+	return common.HttpRequest(m, u, nil, nil, hdr, 204)
+}
@@ -0,0 +1,99 @@
+package gitea
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"net/http"
+	"net/url"
+)
+
+type ListCollaboratorsRequest struct {
+	Page  int
+	Limit int
+}
+
+func (ctx *RepoCtx) ListCollaborators(r *ListCollaboratorsRequest) ([]User, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/collaborators?page=%d&limit=%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.Page, r.Limit)
+	var res []User
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+// same as ListCollaborators but goes through all pages
+func (ctx *RepoCtx) ListAllCollaborators() ([]User, error) {
+	req := ListCollaboratorsRequest{
+		Limit: pageLimit,
+	}
+	var res []User
+	err := forEachPage(func(page int) (int, error) {
+		req.Page = page
+		u, err := ctx.ListCollaborators(&req)
+		res = append(res, u...)
+		return len(u), err
+	})
+	return res, err
+}
+
+type AddCollaboratorOption struct {
+	// read, write or admin
+	Permission string `json:"permission"`
+}
+
+type AddCollaboratorRequest struct {
+	Username string
+	Opt      AddCollaboratorOption
+}
+
+// add collaborator or change permission of existing one
+func (ctx *RepoCtx) AddCollaborator(r *AddCollaboratorRequest) error {
+	const m = "PUT"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/collaborators/%s", ctx.ApiUrl, ctx.Owner, ctx.Repo, url.PathEscape(r.Username))
+	return common.HttpRequest(m, u, &r.Opt, nil, hdr, 204)
+}
+
+type DeleteCollaboratorRequest struct {
+	Username string
+}
+
+func (ctx *RepoCtx) DeleteCollaborator(r *DeleteCollaboratorRequest) error {
+	const m = "DELETE"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/collaborators/%s", ctx.ApiUrl, ctx.Owner, ctx.Repo, url.PathEscape(r.Username))
+	return common.HttpRequest(m, u, nil, nil, hdr, 204)
+}
+
+type RepoPermission struct {
+	// none, read, write, admin or owner
+	Permission string `json:"permission"`
+	RoleName   string `json:"role_name"`
+	User       User   `json:"user"`
+}
+
+type GetCollaboratorPermissionRequest struct {
+	Username string
+}
+
+// effective permission of user, including access through teams
+func (ctx *RepoCtx) GetCollaboratorPermission(r *GetCollaboratorPermissionRequest) (*RepoPermission, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/collaborators/%s/permission", ctx.ApiUrl, ctx.Owner, ctx.Repo, url.PathEscape(r.Username))
+	var res = new(RepoPermission)
+	return res, common.HttpRequest(m, u, nil, res, hdr, 200)
+}
+
+// teams which have access to repository
+func (ctx *RepoCtx) ListRepoTeams() ([]Team, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/teams", ctx.ApiUrl, ctx.Owner, ctx.Repo)
+	var res []Team
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
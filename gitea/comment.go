@@ -0,0 +1,56 @@
+package gitea
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"net/http"
+	"net/url"
+)
+
+// comment on issue or pull request, they share numbering
+type Comment struct {
+	ID   int `json:"id"`
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Body      string `json:"body"`
+	HtmlUrl   string `json:"html_url"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type ListIssueCommentsRequest struct {
+	Index int
+	// RFC3339, only comments updated after it are returned, can be empty
+	Since string
+}
+
+func (ctx *RepoCtx) ListIssueComments(r *ListIssueCommentsRequest) ([]Comment, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.Index)
+	if r.Since != "" {
+		u += "?since=" + url.QueryEscape(r.Since)
+	}
+	var res []Comment
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+type CreateIssueCommentOption struct {
+	Body string `json:"body"`
+}
+
+type CreateIssueCommentRequest struct {
+	Index int
+	Opt   CreateIssueCommentOption
+}
+
+func (ctx *RepoCtx) CreateIssueComment(r *CreateIssueCommentRequest) (*Comment, error) {
+	const m = "POST"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.Index)
+	var res = new(Comment)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 201)
+}
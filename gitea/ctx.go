@@ -0,0 +1,28 @@
+package gitea
+
+import "fmt"
+
+// context for requests which arent bound to single repository
+type Ctx struct {
+	Token  string
+	ApiUrl string
+}
+
+func (ctx *Ctx) Validate() error {
+	if ctx.Token == "" {
+		return fmt.Errorf("validate gitea.Ctx: no auth token provided")
+	}
+	if ctx.ApiUrl == "" {
+		return fmt.Errorf("validate gitea.Ctx: no Api url provided")
+	}
+	return nil
+}
+
+func (ctx *Ctx) RepoCtx(owner, repo string) *RepoCtx {
+	return &RepoCtx{
+		Owner:  owner,
+		Repo:   repo,
+		Token:  ctx.Token,
+		ApiUrl: ctx.ApiUrl,
+	}
+}
@@ -0,0 +1,113 @@
+package gitea
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"net/http"
+)
+
+type Hook struct {
+	ID   int    `json:"id"`
+	Type string `json:"type"`
+	// url, content_type, http_method and type specific settings like channel for slack,
+	// secret is never returned
+	Config       map[string]string `json:"config"`
+	Events       []string          `json:"events"`
+	BranchFilter string            `json:"branch_filter"`
+	Active       bool              `json:"active"`
+	CreatedAt    string            `json:"created_at"`
+	UpdatedAt    string            `json:"updated_at"`
+}
+
+type ListHooksRequest struct {
+	Page  int
+	Limit int
+}
+
+func (ctx *RepoCtx) ListHooks(r *ListHooksRequest) ([]Hook, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/hooks?page=%d&limit=%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.Page, r.Limit)
+	var res []Hook
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+// same as ListHooks but goes through all pages
+func (ctx *RepoCtx) ListAllHooks() ([]Hook, error) {
+	req := ListHooksRequest{
+		Limit: pageLimit,
+	}
+	var res []Hook
+	err := forEachPage(func(page int) (int, error) {
+		req.Page = page
+		h, err := ctx.ListHooks(&req)
+		res = append(res, h...)
+		return len(h), err
+	})
+	return res, err
+}
+
+type CreateHookOption struct {
+	Type         string            `json:"type"`
+	Config       map[string]string `json:"config"`
+	Events       []string          `json:"events"`
+	BranchFilter string            `json:"branch_filter,omitempty"`
+	Active       bool              `json:"active"`
+}
+
+type CreateHookRequest struct {
+	Opt CreateHookOption
+}
+
+func (ctx *RepoCtx) CreateHook(r *CreateHookRequest) (*Hook, error) {
+	const m = "POST"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/hooks", ctx.ApiUrl, ctx.Owner, ctx.Repo)
+	var res = new(Hook)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 201)
+}
+
+// nil fields are left unchanged
+type EditHookOption struct {
+	Config       map[string]string `json:"config,omitempty"`
+	Events       []string          `json:"events,omitempty"`
+	BranchFilter *string           `json:"branch_filter,omitempty"`
+	Active       *bool             `json:"active,omitempty"`
+}
+
+type EditHookRequest struct {
+	ID  int
+	Opt EditHookOption
+}
+
+func (ctx *RepoCtx) EditHook(r *EditHookRequest) (*Hook, error) {
+	const m = "PATCH"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/hooks/%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.ID)
+	var res = new(Hook)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 200)
+}
+
+type HookRequest struct {
+	ID int
+}
+
+func (ctx *RepoCtx) DeleteHook(r *HookRequest) error {
+	const m = "DELETE"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/hooks/%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.ID)
+	return common.HttpRequest(m, u, nil, nil, hdr, 204)
+}
+
+// deliver fake push event to hook
+func (ctx *RepoCtx) TestHook(r *HookRequest) error {
+	const m = "POST"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/hooks/%d/tests", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.ID)
+	return common.HttpRequest(m, u, nil, nil, hdr, 204)
+}
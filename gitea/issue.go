@@ -0,0 +1,169 @@
+package gitea
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type User struct {
+	ID       int    `json:"id"`
+	Login    string `json:"login"`
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+}
+
+type Issue struct {
+	ID        int        `json:"id"`
+	Number    int        `json:"number"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	State     PrState    `json:"state"`
+	User      User       `json:"user"`
+	Labels    []Label    `json:"labels"`
+	Assignees []User     `json:"assignees"`
+	Milestone *Milestone `json:"milestone"`
+	Comments  int        `json:"comments"`
+	HtmlUrl   string     `json:"html_url"`
+	CreatedAt string     `json:"created_at"`
+	UpdatedAt string     `json:"updated_at"`
+	ClosedAt  string     `json:"closed_at,omitempty"`
+	// set when issue is a pull request
+	PullRequest *struct {
+		Merged bool `json:"merged"`
+	} `json:"pull_request,omitempty"`
+}
+
+type ListIssuesRequest struct {
+	// open, closed or all
+	State string
+	// comma separated label names
+	Labels string
+	// comma separated milestone names
+	Milestones string
+	Query      string
+	CreatedBy  string
+	AssignedBy string
+	Page       int
+	Limit      int
+}
+
+func (ctx *RepoCtx) ListIssues(r *ListIssuesRequest) ([]Issue, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	q := url.Values{}
+	q.Set("type", "issues")
+	q.Set("state", r.State)
+	q.Set("page", strconv.Itoa(r.Page))
+	q.Set("limit", strconv.Itoa(r.Limit))
+	if r.Labels != "" {
+		q.Set("labels", r.Labels)
+	}
+	if r.Milestones != "" {
+		q.Set("milestones", r.Milestones)
+	}
+	if r.Query != "" {
+		q.Set("q", r.Query)
+	}
+	if r.CreatedBy != "" {
+		q.Set("created_by", r.CreatedBy)
+	}
+	if r.AssignedBy != "" {
+		q.Set("assigned_by", r.AssignedBy)
+	}
+	var u = fmt.Sprintf("%s/repos/%s/%s/issues?%s", ctx.ApiUrl, ctx.Owner, ctx.Repo, q.Encode())
+	var res []Issue
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+// same as ListIssues but goes through all pages
+func (ctx *RepoCtx) ListAllIssues(r *ListIssuesRequest) ([]Issue, error) {
+	req := *r
+	req.Limit = pageLimit
+	var res []Issue
+	err := forEachPage(func(page int) (int, error) {
+		req.Page = page
+		is, err := ctx.ListIssues(&req)
+		res = append(res, is...)
+		return len(is), err
+	})
+	return res, err
+}
+
+type GetIssueRequest struct {
+	Index int
+}
+
+func (ctx *RepoCtx) GetIssue(r *GetIssueRequest) (*Issue, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/issues/%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.Index)
+	var res = new(Issue)
+	return res, common.HttpRequest(m, u, nil, res, hdr, 200)
+}
+
+type CreateIssueOption struct {
+	Title     string   `json:"title"`
+	Body      string   `json:"body,omitempty"`
+	Assignees []string `json:"assignees,omitempty"`
+	Labels    []int    `json:"labels,omitempty"`
+	Milestone int      `json:"milestone,omitempty"`
+}
+
+type CreateIssueRequest struct {
+	Opt CreateIssueOption
+}
+
+func (ctx *RepoCtx) CreateIssue(r *CreateIssueRequest) (*Issue, error) {
+	const m = "POST"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/issues", ctx.ApiUrl, ctx.Owner, ctx.Repo)
+	var res = new(Issue)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 201)
+}
+
+// nil or empty fields are left unchanged
+type EditIssueOption struct {
+	Title     string   `json:"title,omitempty"`
+	Body      *string  `json:"body,omitempty"`
+	Assignees []string `json:"assignees,omitempty"`
+	Milestone *int     `json:"milestone,omitempty"`
+	State     PrState  `json:"state,omitempty"`
+}
+
+type EditIssueRequest struct {
+	Index int
+	Opt   EditIssueOption
+}
+
+func (ctx *RepoCtx) EditIssue(r *EditIssueRequest) (*Issue, error) {
+	const m = "PATCH"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/issues/%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.Index)
+	var res = new(Issue)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 201)
+}
+
+type ReplaceIssueLabelsOption struct {
+	Labels []int `json:"labels"`
+}
+
+type ReplaceIssueLabelsRequest struct {
+	Index int
+	Opt   ReplaceIssueLabelsOption
+}
+
+func (ctx *RepoCtx) ReplaceIssueLabels(r *ReplaceIssueLabelsRequest) ([]Label, error) {
+	const m = "PUT"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/issues/%d/labels", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.Index)
+	var res []Label
+	return res, common.HttpRequest(m, u, &r.Opt, &res, hdr, 200)
+}
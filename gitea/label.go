@@ -0,0 +1,122 @@
+package gitea
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"net/http"
+)
+
+type Label struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+	Exclusive   bool   `json:"exclusive"`
+}
+
+type ListLabelsRequest struct {
+	Page  int
+	Limit int
+}
+
+func (ctx *RepoCtx) ListLabels(r *ListLabelsRequest) ([]Label, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/labels?page=%d&limit=%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.Page, r.Limit)
+	var res []Label
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+// same as ListLabels but goes through all pages
+func (ctx *RepoCtx) ListAllLabels() ([]Label, error) {
+	req := ListLabelsRequest{
+		Limit: pageLimit,
+	}
+	var res []Label
+	err := forEachPage(func(page int) (int, error) {
+		req.Page = page
+		l, err := ctx.ListLabels(&req)
+		res = append(res, l...)
+		return len(l), err
+	})
+	return res, err
+}
+
+func (ctx *Ctx) ListOrgLabels(org string, r *ListLabelsRequest) ([]Label, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/orgs/%s/labels?page=%d&limit=%d", ctx.ApiUrl, org, r.Page, r.Limit)
+	var res []Label
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+// same as ListOrgLabels but goes through all pages
+func (ctx *Ctx) ListAllOrgLabels(org string) ([]Label, error) {
+	req := ListLabelsRequest{
+		Limit: pageLimit,
+	}
+	var res []Label
+	err := forEachPage(func(page int) (int, error) {
+		req.Page = page
+		l, err := ctx.ListOrgLabels(org, &req)
+		res = append(res, l...)
+		return len(l), err
+	})
+	return res, err
+}
+
+type CreateLabelOption struct {
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+	Exclusive   bool   `json:"exclusive"`
+}
+
+type CreateLabelRequest struct {
+	Opt CreateLabelOption
+}
+
+func (ctx *RepoCtx) CreateLabel(r *CreateLabelRequest) (*Label, error) {
+	const m = "POST"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/labels", ctx.ApiUrl, ctx.Owner, ctx.Repo)
+	var res = new(Label)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 201)
+}
+
+// nil fields are left unchanged
+type EditLabelOption struct {
+	Name        *string `json:"name,omitempty"`
+	Color       *string `json:"color,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Exclusive   *bool   `json:"exclusive,omitempty"`
+}
+
+type EditLabelRequest struct {
+	ID  int
+	Opt EditLabelOption
+}
+
+func (ctx *RepoCtx) EditLabel(r *EditLabelRequest) (*Label, error) {
+	const m = "PATCH"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/labels/%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.ID)
+	var res = new(Label)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 200)
+}
+
+type DeleteLabelRequest struct {
+	ID int
+}
+
+func (ctx *RepoCtx) DeleteLabel(r *DeleteLabelRequest) error {
+	const m = "DELETE"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/labels/%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.ID)
+	return common.HttpRequest(m, u, nil, nil, hdr, 204)
+}
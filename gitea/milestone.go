@@ -0,0 +1,103 @@
+package gitea
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"net/http"
+)
+
+type Milestone struct {
+	ID           int    `json:"id"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	State        string `json:"state"`
+	OpenIssues   int    `json:"open_issues"`
+	ClosedIssues int    `json:"closed_issues"`
+	DueOn        string `json:"due_on,omitempty"`
+}
+
+type ListMilestonesRequest struct {
+	// open, closed or all
+	State string
+	Page  int
+	Limit int
+}
+
+func (ctx *RepoCtx) ListMilestones(r *ListMilestonesRequest) ([]Milestone, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/milestones?state=%s&page=%d&limit=%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.State, r.Page, r.Limit)
+	var res []Milestone
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+// same as ListMilestones but goes through all pages
+func (ctx *RepoCtx) ListAllMilestones(r *ListMilestonesRequest) ([]Milestone, error) {
+	req := *r
+	req.Limit = pageLimit
+	var res []Milestone
+	err := forEachPage(func(page int) (int, error) {
+		req.Page = page
+		ms, err := ctx.ListMilestones(&req)
+		res = append(res, ms...)
+		return len(ms), err
+	})
+	return res, err
+}
+
+type CreateMilestoneOption struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	// RFC3339
+	DueOn string `json:"due_on,omitempty"`
+}
+
+type CreateMilestoneRequest struct {
+	Opt CreateMilestoneOption
+}
+
+func (ctx *RepoCtx) CreateMilestone(r *CreateMilestoneRequest) (*Milestone, error) {
+	const m = "POST"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/milestones", ctx.ApiUrl, ctx.Owner, ctx.Repo)
+	var res = new(Milestone)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 201)
+}
+
+// nil or empty fields are left unchanged
+type EditMilestoneOption struct {
+	Title       string  `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	// RFC3339
+	DueOn string `json:"due_on,omitempty"`
+	// open or closed
+	State string `json:"state,omitempty"`
+}
+
+type EditMilestoneRequest struct {
+	ID  int
+	Opt EditMilestoneOption
+}
+
+func (ctx *RepoCtx) EditMilestone(r *EditMilestoneRequest) (*Milestone, error) {
+	const m = "PATCH"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/milestones/%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.ID)
+	var res = new(Milestone)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 200)
+}
+
+type DeleteMilestoneRequest struct {
+	ID int
+}
+
+func (ctx *RepoCtx) DeleteMilestone(r *DeleteMilestoneRequest) error {
+	const m = "DELETE"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/milestones/%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.ID)
+	return common.HttpRequest(m, u, nil, nil, hdr, 204)
+}
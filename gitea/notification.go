@@ -0,0 +1,113 @@
+package gitea
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type NotificationSubject struct {
+	Title string `json:"title"`
+	// api url of issue, pull request, commit or release
+	Url     string `json:"url"`
+	HtmlUrl string `json:"html_url"`
+	// Issue, Pull, Commit or Repository
+	Type                 string `json:"type"`
+	State                string `json:"state"`
+	LatestCommentHtmlUrl string `json:"latest_comment_html_url"`
+}
+
+type NotificationThread struct {
+	ID         int                 `json:"id"`
+	Repository *Repository         `json:"repository"`
+	Subject    NotificationSubject `json:"subject"`
+	Unread     bool                `json:"unread"`
+	Pinned     bool                `json:"pinned"`
+	UpdatedAt  string              `json:"updated_at"`
+}
+
+type ListNotificationsRequest struct {
+	// include read notifications
+	All bool
+	// Issue, Pull, Commit or Repository, all when empty
+	SubjectTypes []string
+	// RFC3339, can be empty
+	Since string
+	Page  int
+	Limit int
+}
+
+func (ctx *Ctx) ListNotifications(r *ListNotificationsRequest) ([]NotificationThread, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	q := url.Values{}
+	q.Set("all", strconv.FormatBool(r.All))
+	q.Set("page", strconv.Itoa(r.Page))
+	q.Set("limit", strconv.Itoa(r.Limit))
+	for _, t := range r.SubjectTypes {
+		q.Add("subject-type", t)
+	}
+	if r.Since != "" {
+		q.Set("since", r.Since)
+	}
+	var u = fmt.Sprintf("%s/notifications?%s", ctx.ApiUrl, q.Encode())
+	var res []NotificationThread
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+// same as ListNotifications but goes through all pages
+func (ctx *Ctx) ListAllNotifications(r *ListNotificationsRequest) ([]NotificationThread, error) {
+	req := *r
+	req.Limit = pageLimit
+	var res []NotificationThread
+	err := forEachPage(func(page int) (int, error) {
+		req.Page = page
+		n, err := ctx.ListNotifications(&req)
+		res = append(res, n...)
+		return len(n), err
+	})
+	return res, err
+}
+
+type NotificationThreadRequest struct {
+	ID int
+}
+
+func (ctx *Ctx) GetNotificationThread(r *NotificationThreadRequest) (*NotificationThread, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/notifications/threads/%d", ctx.ApiUrl, r.ID)
+	var res = new(NotificationThread)
+	return res, common.HttpRequest(m, u, nil, res, hdr, 200)
+}
+
+func (ctx *Ctx) ReadNotificationThread(r *NotificationThreadRequest) error {
+	const m = "PATCH"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/notifications/threads/%d?to-status=read", ctx.ApiUrl, r.ID)
+	return common.HttpRequest(m, u, nil, nil, hdr, 205)
+}
+
+type ReadNotificationsRequest struct {
+	// RFC3339, notifications updated after it stay unread, now when empty
+	LastReadAt string
+}
+
+// mark all unread notifications as read
+func (ctx *Ctx) ReadAllNotifications(r *ReadNotificationsRequest) error {
+	const m = "PUT"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	q := url.Values{}
+	q.Set("to-status", "read")
+	if r.LastReadAt != "" {
+		q.Set("last_read_at", r.LastReadAt)
+	}
+	var u = fmt.Sprintf("%s/notifications?%s", ctx.ApiUrl, q.Encode())
+	return common.HttpRequest(m, u, nil, nil, hdr, 205)
+}
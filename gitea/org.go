@@ -0,0 +1,55 @@
+package gitea
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"net/http"
+)
+
+type Organization struct {
+	ID          int    `json:"id"`
+	Name        string `json:"username"`
+	FullName    string `json:"full_name"`
+	Description string `json:"description"`
+	Website     string `json:"website"`
+	Visibility  string `json:"visibility"`
+}
+
+type ListOrgsRequest struct {
+	Page  int
+	Limit int
+}
+
+func (ctx *Ctx) listOrgs(u string, r *ListOrgsRequest) ([]Organization, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	u = fmt.Sprintf("%s?page=%d&limit=%d", u, r.Page, r.Limit)
+	var res []Organization
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+// organizations current user is member of
+func (ctx *Ctx) ListMyOrgs(r *ListOrgsRequest) ([]Organization, error) {
+	return ctx.listOrgs(fmt.Sprintf("%s/user/orgs", ctx.ApiUrl), r)
+}
+
+// every organization visible to current user
+func (ctx *Ctx) ListOrgs(r *ListOrgsRequest) ([]Organization, error) {
+	return ctx.listOrgs(fmt.Sprintf("%s/orgs", ctx.ApiUrl), r)
+}
+
+// same as ListMyOrgs or ListOrgs when all is set but goes through all pages
+func (ctx *Ctx) ListAllOrgs(all bool) ([]Organization, error) {
+	list := ctx.ListMyOrgs
+	if all {
+		list = ctx.ListOrgs
+	}
+	var res []Organization
+	err := forEachPage(func(page int) (int, error) {
+		orgs, err := list(&ListOrgsRequest{Page: page, Limit: pageLimit})
+		res = append(res, orgs...)
+		return len(orgs), err
+	})
+	return res, err
+}
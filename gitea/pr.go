@@ -6,12 +6,36 @@ import (
 	"net/http"
 )
 
+// true when head branch lives in another repository than base, e.g. in a fork
+func (pr *PullRequest) IsCrossRepo() bool {
+	return pr.Head.RepoID != 0 && pr.Head.RepoID != pr.Base.RepoID
+}
+
+// head branch, prefixed with owner of head repository for cross repository prs
+func (pr *PullRequest) HeadName() string {
+	if pr.IsCrossRepo() && pr.Head.Repo != nil {
+		return pr.Head.Repo.Owner.Login + ":" + pr.Head.Ref
+	}
+	return pr.Head.Ref
+}
+
 type ListPRRequest struct {
 	State string
+	// eg. recentupdate, server default when empty
+	Sort string
+	// pagination, server defaults are used when 0
+	Page  int
+	Limit int
 }
 
 type PRBranchInfo struct {
-	Ref string `json:"ref"`
+	// owner:branch for branches of another repository, otherwise same as Ref
+	Label  string `json:"label"`
+	Ref    string `json:"ref"`
+	Sha    string `json:"sha"`
+	RepoID int    `json:"repo_id"`
+	// nil when repository was deleted
+	Repo *Repository `json:"repo"`
 }
 
 type PullRequest struct {
@@ -21,8 +45,18 @@ type PullRequest struct {
 	User  struct {
 		Login string `json:"login"`
 	} `json:"user"`
-	Base, Head PRBranchInfo
-	Number     int `json:"number"`
+	Body           string `json:"body"`
+	Base, Head     PRBranchInfo
+	Number         int     `json:"number"`
+	State          PrState `json:"state"`
+	Merged         bool    `json:"merged"`
+	MergedAt       string  `json:"merged_at,omitempty"`
+	MergeCommitSha string  `json:"merge_commit_sha,omitempty"`
+	Labels         []Label `json:"labels"`
+	Comments       int     `json:"comments"`
+	UpdatedAt      string  `json:"updated_at"`
+	// users asked for review who havent reviewed yet
+	RequestedReviewers []User `json:"requested_reviewers"`
 }
 
 func (ctx *RepoCtx) ListPR(r *ListPRRequest) ([]PullRequest, error) {
@@ -30,16 +64,86 @@ func (ctx *RepoCtx) ListPR(r *ListPRRequest) ([]PullRequest, error) {
 	hdr := make(http.Header)
 	hdr.Add("Authorization", "token "+ctx.Token)
 	var u = fmt.Sprintf("%s/repos/%s/%s/pulls?state=%s", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.State)
+	if r.Page > 0 {
+		u += fmt.Sprintf("&page=%d", r.Page)
+	}
+	if r.Limit > 0 {
+		u += fmt.Sprintf("&limit=%d", r.Limit)
+	}
+	if r.Sort != "" {
+		u += "&sort=" + r.Sort
+	}
 	var res []PullRequest
 	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
 }
 
+// same as ListPR but goes through all pages
+func (ctx *RepoCtx) ListAllPR(r *ListPRRequest) ([]PullRequest, error) {
+	req := *r
+	req.Limit = pageLimit
+	var res []PullRequest
+	err := forEachPage(func(page int) (int, error) {
+		req.Page = page
+		prs, err := ctx.ListPR(&req)
+		res = append(res, prs...)
+		return len(prs), err
+	})
+	return res, err
+}
+
+// same as ListAllPR but stops paging at first pr for which stop returns true, that one is not included
+func (ctx *RepoCtx) ListPRUntil(r *ListPRRequest, stop func(pr *PullRequest) bool) ([]PullRequest, error) {
+	req := *r
+	req.Limit = pageLimit
+	var res []PullRequest
+	err := forEachPage(func(page int) (int, error) {
+		req.Page = page
+		prs, err := ctx.ListPR(&req)
+		for i := range prs {
+			if stop(&prs[i]) {
+				return 0, nil
+			}
+			res = append(res, prs[i])
+		}
+		return len(prs), err
+	})
+	return res, err
+}
+
+type GetPRRequest struct {
+	Index int
+}
+
+func (ctx *RepoCtx) GetPR(r *GetPRRequest) (*PullRequest, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/pulls/%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.Index)
+	var res = new(PullRequest)
+	return res, common.HttpRequest(m, u, nil, res, hdr, 200)
+}
+
+type GetPRDiffRequest struct {
+	Index int
+}
+
+// unified diff of pull request
+func (ctx *RepoCtx) GetPRDiff(r *GetPRDiffRequest) (string, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/pulls/%d.diff", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.Index)
+	b, err := common.HttpRequestRaw(m, u, nil, hdr, 200)
+	return string(b), err
+}
+
 type CreatePullRequestOption struct {
 	//Assignee  string   `json:"assignee"`
 	//Assignees []string `json:"assignees"`
 	Base string `json:"base"`
-	//Body string `json:"body"`
+	Body string `json:"body,omitempty"`
 	//DueDate   time.Time `json:"due_date"`
+	// branch name, or owner:branch when it lives in a fork
 	Head string `json:"head"`
 	//Labels    []string  `json:"labels"`
 	//Milestone int       `json:"milestone"`
@@ -85,8 +189,11 @@ const (
 )
 
 type EditPullRequestOption struct {
-	State PrState
-	Title string
+	State PrState `json:"state,omitempty"`
+	Title string  `json:"title,omitempty"`
+	Base  string  `json:"base,omitempty"`
+	// nil leaves body unchanged
+	Body *string `json:"body,omitempty"`
 }
 
 type UpdatePrRequest struct {
@@ -0,0 +1,171 @@
+package gitea
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+type Attachment struct {
+	ID                 int    `json:"id"`
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	DownloadCount      int    `json:"download_count"`
+	CreatedAt          string `json:"created_at"`
+	BrowserDownloadUrl string `json:"browser_download_url"`
+}
+
+type Release struct {
+	ID              int          `json:"id"`
+	TagName         string       `json:"tag_name"`
+	TargetCommitish string       `json:"target_commitish"`
+	Name            string       `json:"name"`
+	Body            string       `json:"body"`
+	Draft           bool         `json:"draft"`
+	Prerelease      bool         `json:"prerelease"`
+	HtmlUrl         string       `json:"html_url"`
+	CreatedAt       string       `json:"created_at"`
+	PublishedAt     string       `json:"published_at"`
+	Author          User         `json:"author"`
+	Assets          []Attachment `json:"assets"`
+}
+
+type ListReleasesRequest struct {
+	Page  int
+	Limit int
+}
+
+func (ctx *RepoCtx) ListReleases(r *ListReleasesRequest) ([]Release, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/releases?page=%d&limit=%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.Page, r.Limit)
+	var res []Release
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+// same as ListReleases but goes through all pages
+func (ctx *RepoCtx) ListAllReleases() ([]Release, error) {
+	req := ListReleasesRequest{
+		Limit: pageLimit,
+	}
+	var res []Release
+	err := forEachPage(func(page int) (int, error) {
+		req.Page = page
+		rs, err := ctx.ListReleases(&req)
+		res = append(res, rs...)
+		return len(rs), err
+	})
+	return res, err
+}
+
+type GetReleaseByTagRequest struct {
+	Tag string
+}
+
+func (ctx *RepoCtx) GetReleaseByTag(r *GetReleaseByTagRequest) (*Release, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", ctx.ApiUrl, ctx.Owner, ctx.Repo, url.PathEscape(r.Tag))
+	var res = new(Release)
+	return res, common.HttpRequest(m, u, nil, res, hdr, 200)
+}
+
+type CreateReleaseOption struct {
+	TagName         string `json:"tag_name"`
+	TargetCommitish string `json:"target_commitish,omitempty"`
+	Name            string `json:"name"`
+	Body            string `json:"body"`
+	Draft           bool   `json:"draft"`
+	Prerelease      bool   `json:"prerelease"`
+}
+
+type CreateReleaseRequest struct {
+	Opt CreateReleaseOption
+}
+
+func (ctx *RepoCtx) CreateRelease(r *CreateReleaseRequest) (*Release, error) {
+	const m = "POST"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/releases", ctx.ApiUrl, ctx.Owner, ctx.Repo)
+	var res = new(Release)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 201)
+}
+
+// nil or empty fields are left unchanged
+type EditReleaseOption struct {
+	Name       string  `json:"name,omitempty"`
+	Body       *string `json:"body,omitempty"`
+	Draft      *bool   `json:"draft,omitempty"`
+	Prerelease *bool   `json:"prerelease,omitempty"`
+}
+
+type EditReleaseRequest struct {
+	ID  int
+	Opt EditReleaseOption
+}
+
+func (ctx *RepoCtx) EditRelease(r *EditReleaseRequest) (*Release, error) {
+	const m = "PATCH"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/releases/%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.ID)
+	var res = new(Release)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 200)
+}
+
+type DeleteReleaseRequest struct {
+	ID int
+}
+
+func (ctx *RepoCtx) DeleteRelease(r *DeleteReleaseRequest) error {
+	const m = "DELETE"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/releases/%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.ID)
+	return common.HttpRequest(m, u, nil, nil, hdr, 204)
+}
+
+type ListReleaseAssetsRequest struct {
+	ReleaseID int
+}
+
+func (ctx *RepoCtx) ListReleaseAssets(r *ListReleaseAssetsRequest) ([]Attachment, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/releases/%d/assets", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.ReleaseID)
+	var res []Attachment
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+type CreateReleaseAssetRequest struct {
+	ReleaseID int
+	Name      string
+	Content   io.Reader
+}
+
+func (ctx *RepoCtx) CreateReleaseAsset(r *CreateReleaseAssetRequest) (*Attachment, error) {
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/releases/%d/assets?name=%s", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.ReleaseID, url.QueryEscape(r.Name))
+	var res = new(Attachment)
+	return res, common.HttpUpload(u, "attachment", r.Name, r.Content, res, hdr, 201)
+}
+
+type DeleteReleaseAssetRequest struct {
+	ReleaseID int
+	AssetID   int
+}
+
+func (ctx *RepoCtx) DeleteReleaseAsset(r *DeleteReleaseAssetRequest) error {
+	const m = "DELETE"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/releases/%d/assets/%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.ReleaseID, r.AssetID)
+	return common.HttpRequest(m, u, nil, nil, hdr, 204)
+}
@@ -0,0 +1,272 @@
+package gitea
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type Repository struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Owner    struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+	Description   string   `json:"description"`
+	Private       bool     `json:"private"`
+	Fork          bool     `json:"fork"`
+	Mirror        bool     `json:"mirror"`
+	Archived      bool     `json:"archived"`
+	Empty         bool     `json:"empty"`
+	DefaultBranch string   `json:"default_branch"`
+	HtmlUrl       string   `json:"html_url"`
+	CloneUrl      string   `json:"clone_url"`
+	SshUrl        string   `json:"ssh_url"`
+	Template      bool     `json:"template"`
+	Language      string   `json:"language"`
+	Topics        []string `json:"topics"`
+	// set for forks
+	Parent          *Repository `json:"parent,omitempty"`
+	StarsCount      int         `json:"stars_count"`
+	ForksCount      int         `json:"forks_count"`
+	OpenIssuesCount int         `json:"open_issues_count"`
+	OpenPrCounter   int         `json:"open_pr_counter"`
+	Size            int         `json:"size"`
+	CreatedAt       string      `json:"created_at"`
+	UpdatedAt       string      `json:"updated_at"`
+}
+
+type ListReposRequest struct {
+	Page  int
+	Limit int
+}
+
+func (ctx *Ctx) listRepos(u string, r *ListReposRequest) ([]Repository, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	u = fmt.Sprintf("%s?page=%d&limit=%d", u, r.Page, r.Limit)
+	var res []Repository
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+func (ctx *Ctx) ListOrgRepos(org string, r *ListReposRequest) ([]Repository, error) {
+	return ctx.listRepos(fmt.Sprintf("%s/orgs/%s/repos", ctx.ApiUrl, url.PathEscape(org)), r)
+}
+
+func (ctx *Ctx) ListUserRepos(user string, r *ListReposRequest) ([]Repository, error) {
+	return ctx.listRepos(fmt.Sprintf("%s/users/%s/repos", ctx.ApiUrl, url.PathEscape(user)), r)
+}
+
+// repositories of authenticated user, including ones of their organizations
+func (ctx *Ctx) ListCurrentUserRepos(r *ListReposRequest) ([]Repository, error) {
+	return ctx.listRepos(fmt.Sprintf("%s/user/repos", ctx.ApiUrl), r)
+}
+
+// same as ListCurrentUserRepos but goes through all pages
+func (ctx *Ctx) ListAllCurrentUserRepos() ([]Repository, error) {
+	var res []Repository
+	err := forEachPage(func(page int) (int, error) {
+		repos, err := ctx.ListCurrentUserRepos(&ListReposRequest{Page: page, Limit: pageLimit})
+		res = append(res, repos...)
+		return len(repos), err
+	})
+	return res, err
+}
+
+// all repositories of organization or user
+func (ctx *Ctx) ListAllOwnerRepos(owner string) ([]Repository, error) {
+	list := ctx.ListOrgRepos
+	var res []Repository
+	err := forEachPage(func(page int) (int, error) {
+		repos, err := list(owner, &ListReposRequest{Page: page, Limit: pageLimit})
+		if page == 1 && common.IsStatus(err, 404) {
+			// not an organization
+			list = ctx.ListUserRepos
+			repos, err = list(owner, &ListReposRequest{Page: page, Limit: pageLimit})
+		}
+		res = append(res, repos...)
+		return len(repos), err
+	})
+	return res, err
+}
+
+type SearchReposRequest struct {
+	Query string
+	// match query against topics only
+	Topic bool
+	// filters are skipped when nil
+	Private  *bool
+	Archived *bool
+	// fork, source, mirror or collaborative
+	Mode  string
+	Page  int
+	Limit int
+}
+
+type searchReposResponse struct {
+	Ok   bool         `json:"ok"`
+	Data []Repository `json:"data"`
+}
+
+func (ctx *Ctx) SearchRepos(r *SearchReposRequest) ([]Repository, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	q := url.Values{}
+	q.Set("q", r.Query)
+	q.Set("page", strconv.Itoa(r.Page))
+	q.Set("limit", strconv.Itoa(r.Limit))
+	if r.Topic {
+		q.Set("topic", "true")
+	}
+	if r.Private != nil {
+		q.Set("is_private", strconv.FormatBool(*r.Private))
+	}
+	if r.Archived != nil {
+		q.Set("archived", strconv.FormatBool(*r.Archived))
+	}
+	if r.Mode != "" {
+		q.Set("mode", r.Mode)
+	}
+	var u = fmt.Sprintf("%s/repos/search?%s", ctx.ApiUrl, q.Encode())
+	var res searchReposResponse
+	if err := common.HttpRequest(m, u, nil, &res, hdr, 200); err != nil {
+		return nil, err
+	}
+	if !res.Ok {
+		return nil, fmt.Errorf("repository search wasn't successful")
+	}
+	return res.Data, nil
+}
+
+// same as SearchRepos but goes through all pages
+func (ctx *Ctx) SearchAllRepos(r *SearchReposRequest) ([]Repository, error) {
+	req := *r
+	req.Limit = pageLimit
+	var res []Repository
+	err := forEachPage(func(page int) (int, error) {
+		req.Page = page
+		repos, err := ctx.SearchRepos(&req)
+		res = append(res, repos...)
+		return len(repos), err
+	})
+	return res, err
+}
+
+func (ctx *RepoCtx) GetRepo() (*Repository, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s", ctx.ApiUrl, ctx.Owner, ctx.Repo)
+	var res = new(Repository)
+	return res, common.HttpRequest(m, u, nil, res, hdr, 200)
+}
+
+type CreateRepoOption struct {
+	Name          string `json:"name"`
+	Description   string `json:"description,omitempty"`
+	Private       bool   `json:"private"`
+	Template      bool   `json:"template"`
+	AutoInit      bool   `json:"auto_init"`
+	Gitignores    string `json:"gitignores,omitempty"`
+	License       string `json:"license,omitempty"`
+	Readme        string `json:"readme,omitempty"`
+	DefaultBranch string `json:"default_branch,omitempty"`
+}
+
+type CreateRepoRequest struct {
+	// repository is created for authenticated user when empty
+	Org string
+	Opt CreateRepoOption
+}
+
+func (ctx *Ctx) CreateRepo(r *CreateRepoRequest) (*Repository, error) {
+	const m = "POST"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/user/repos", ctx.ApiUrl)
+	if r.Org != "" {
+		u = fmt.Sprintf("%s/orgs/%s/repos", ctx.ApiUrl, url.PathEscape(r.Org))
+	}
+	var res = new(Repository)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 201)
+}
+
+type GenerateRepoOption struct {
+	Owner         string `json:"owner"`
+	Name          string `json:"name"`
+	Description   string `json:"description,omitempty"`
+	Private       bool   `json:"private"`
+	DefaultBranch string `json:"default_branch,omitempty"`
+	GitContent    bool   `json:"git_content"`
+	Topics        bool   `json:"topics"`
+	Labels        bool   `json:"labels"`
+	Webhooks      bool   `json:"git_hooks"`
+}
+
+type GenerateRepoRequest struct {
+	Opt GenerateRepoOption
+}
+
+// create new repository using ctx repository as template
+func (ctx *RepoCtx) GenerateRepo(r *GenerateRepoRequest) (*Repository, error) {
+	const m = "POST"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/generate", ctx.ApiUrl, ctx.Owner, ctx.Repo)
+	var res = new(Repository)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 201)
+}
+
+type CreateForkOption struct {
+	// fork into this organization instead of user
+	Organization string `json:"organization,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+type CreateForkRequest struct {
+	Opt CreateForkOption
+}
+
+func (ctx *RepoCtx) CreateFork(r *CreateForkRequest) (*Repository, error) {
+	const m = "POST"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/forks", ctx.ApiUrl, ctx.Owner, ctx.Repo)
+	var res = new(Repository)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 202)
+}
+
+// nil fields are left unchanged
+type EditRepoOption struct {
+	Description   *string `json:"description,omitempty"`
+	Private       *bool   `json:"private,omitempty"`
+	Template      *bool   `json:"template,omitempty"`
+	Archived      *bool   `json:"archived,omitempty"`
+	DefaultBranch *string `json:"default_branch,omitempty"`
+}
+
+type EditRepoRequest struct {
+	Opt EditRepoOption
+}
+
+func (ctx *RepoCtx) EditRepo(r *EditRepoRequest) (*Repository, error) {
+	const m = "PATCH"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s", ctx.ApiUrl, ctx.Owner, ctx.Repo)
+	var res = new(Repository)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 200)
+}
+
+func (ctx *RepoCtx) DeleteRepo() error {
+	const m = "DELETE"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s", ctx.ApiUrl, ctx.Owner, ctx.Repo)
+	return common.HttpRequest(m, u, nil, nil, hdr, 204)
+}
@@ -1 +1,17 @@
 package gitea
+
+// page size used when going through all pages
+const pageLimit = 50
+
+// call fetch with increasing page number until it returns no items
+func forEachPage(fetch func(page int) (int, error)) error {
+	for page := 1; ; page++ {
+		n, err := fetch(page)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+	}
+}
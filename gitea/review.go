@@ -0,0 +1,122 @@
+package gitea
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"net/http"
+)
+
+type ReviewState string
+
+const (
+	ReviewApproved       ReviewState = "APPROVED"
+	ReviewPending        ReviewState = "PENDING"
+	ReviewComment        ReviewState = "COMMENT"
+	ReviewRequestChanges ReviewState = "REQUEST_CHANGES"
+	ReviewRequestReview  ReviewState = "REQUEST_REVIEW"
+)
+
+type PullReview struct {
+	ID   int `json:"id"`
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	State       ReviewState `json:"state"`
+	Body        string      `json:"body"`
+	CommitID    string      `json:"commit_id"`
+	Stale       bool        `json:"stale"`
+	Official    bool        `json:"official"`
+	Dismissed   bool        `json:"dismissed"`
+	SubmittedAt string      `json:"submitted_at"`
+	HtmlUrl     string      `json:"html_url"`
+}
+
+type ListReviewsRequest struct {
+	Index int
+}
+
+func (ctx *RepoCtx) ListReviews(r *ListReviewsRequest) ([]PullReview, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.Index)
+	var res []PullReview
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+type CreatePullReviewComment struct {
+	Path string `json:"path"`
+	Body string `json:"body"`
+	// line in the old file, 0 if comment is on added line
+	OldPosition int `json:"old_position"`
+	// line in the new file, 0 if comment is on removed line
+	NewPosition int `json:"new_position"`
+}
+
+type CreatePullReviewOptions struct {
+	Body     string                    `json:"body"`
+	Event    ReviewState               `json:"event"`
+	CommitID string                    `json:"commit_id,omitempty"`
+	Comments []CreatePullReviewComment `json:"comments,omitempty"`
+}
+
+type CreateReviewRequest struct {
+	Index int
+	Opt   CreatePullReviewOptions
+}
+
+func (ctx *RepoCtx) CreateReview(r *CreateReviewRequest) (*PullReview, error) {
+	const m = "POST"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.Index)
+	var res = new(PullReview)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 200)
+}
+
+type DismissPullReviewOptions struct {
+	Message string `json:"message"`
+}
+
+type DismissReviewRequest struct {
+	Index    int
+	ReviewID int
+	Opt      DismissPullReviewOptions
+}
+
+func (ctx *RepoCtx) DismissReview(r *DismissReviewRequest) error {
+	const m = "POST"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews/%d/dismissals", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.Index, r.ReviewID)
+	return common.HttpRequest(m, u, &r.Opt, nil, hdr, 200)
+}
+
+type PullReviewComment struct {
+	ID   int `json:"id"`
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Body             string `json:"body"`
+	Path             string `json:"path"`
+	Position         int    `json:"position"`
+	OriginalPosition int    `json:"original_position"`
+	DiffHunk         string `json:"diff_hunk"`
+	ReviewID         int    `json:"pull_request_review_id"`
+	HtmlUrl          string `json:"html_url"`
+	CreatedAt        string `json:"created_at"`
+}
+
+type ListReviewCommentsRequest struct {
+	Index    int
+	ReviewID int
+}
+
+func (ctx *RepoCtx) ListReviewComments(r *ListReviewCommentsRequest) ([]PullReviewComment, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews/%d/comments", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.Index, r.ReviewID)
+	var res []PullReviewComment
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
@@ -0,0 +1,94 @@
+package gitea
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"net/http"
+	"net/url"
+)
+
+type Tag struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	ID      string `json:"id"`
+	Commit  struct {
+		Sha     string `json:"sha"`
+		Created string `json:"created"`
+	} `json:"commit"`
+}
+
+type ListTagsRequest struct {
+	Page  int
+	Limit int
+}
+
+// tags are returned newest first
+func (ctx *RepoCtx) ListTags(r *ListTagsRequest) ([]Tag, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/tags?page=%d&limit=%d", ctx.ApiUrl, ctx.Owner, ctx.Repo, r.Page, r.Limit)
+	var res []Tag
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+// same as ListTags but goes through all pages
+func (ctx *RepoCtx) ListAllTags() ([]Tag, error) {
+	req := ListTagsRequest{
+		Limit: pageLimit,
+	}
+	var res []Tag
+	err := forEachPage(func(page int) (int, error) {
+		req.Page = page
+		t, err := ctx.ListTags(&req)
+		res = append(res, t...)
+		return len(t), err
+	})
+	return res, err
+}
+
+type GetTagRequest struct {
+	Tag string
+}
+
+func (ctx *RepoCtx) GetTag(r *GetTagRequest) (*Tag, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/tags/%s", ctx.ApiUrl, ctx.Owner, ctx.Repo, url.PathEscape(r.Tag))
+	var res = new(Tag)
+	return res, common.HttpRequest(m, u, nil, res, hdr, 200)
+}
+
+type CreateTagOption struct {
+	TagName string `json:"tag_name"`
+	// annotated tag is created when message is set
+	Message string `json:"message,omitempty"`
+	// branch or commit, default branch if empty
+	Target string `json:"target,omitempty"`
+}
+
+type CreateTagRequest struct {
+	Opt CreateTagOption
+}
+
+func (ctx *RepoCtx) CreateTag(r *CreateTagRequest) (*Tag, error) {
+	const m = "POST"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/tags", ctx.ApiUrl, ctx.Owner, ctx.Repo)
+	var res = new(Tag)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 201)
+}
+
+type DeleteTagRequest struct {
+	Tag string
+}
+
+func (ctx *RepoCtx) DeleteTag(r *DeleteTagRequest) error {
+	const m = "DELETE"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/repos/%s/%s/tags/%s", ctx.ApiUrl, ctx.Owner, ctx.Repo, url.PathEscape(r.Tag))
+	return common.HttpRequest(m, u, nil, nil, hdr, 204)
+}
@@ -0,0 +1,187 @@
+package gitea
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"net/http"
+	"net/url"
+)
+
+type TeamPermission string
+
+const (
+	TeamRead  TeamPermission = "read"
+	TeamWrite TeamPermission = "write"
+	TeamAdmin TeamPermission = "admin"
+	TeamOwner TeamPermission = "owner"
+)
+
+type Team struct {
+	ID                      int               `json:"id"`
+	Name                    string            `json:"name"`
+	Description             string            `json:"description"`
+	Permission              TeamPermission    `json:"permission"`
+	Units                   []string          `json:"units"`
+	UnitsMap                map[string]string `json:"units_map"`
+	IncludesAllRepositories bool              `json:"includes_all_repositories"`
+	CanCreateOrgRepo        bool              `json:"can_create_org_repo"`
+}
+
+type ListTeamsRequest struct {
+	Page  int
+	Limit int
+}
+
+func (ctx *Ctx) ListOrgTeams(org string, r *ListTeamsRequest) ([]Team, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/orgs/%s/teams?page=%d&limit=%d", ctx.ApiUrl, url.PathEscape(org), r.Page, r.Limit)
+	var res []Team
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+// same as ListOrgTeams but goes through all pages
+func (ctx *Ctx) ListAllOrgTeams(org string) ([]Team, error) {
+	req := ListTeamsRequest{
+		Limit: pageLimit,
+	}
+	var res []Team
+	err := forEachPage(func(page int) (int, error) {
+		req.Page = page
+		t, err := ctx.ListOrgTeams(org, &req)
+		res = append(res, t...)
+		return len(t), err
+	})
+	return res, err
+}
+
+type CreateTeamOption struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Permission  TeamPermission `json:"permission"`
+	// eg. repo.code, repo.issues, repo.pulls
+	Units                   []string `json:"units,omitempty"`
+	IncludesAllRepositories bool     `json:"includes_all_repositories"`
+	CanCreateOrgRepo        bool     `json:"can_create_org_repo"`
+}
+
+type CreateTeamRequest struct {
+	Org string
+	Opt CreateTeamOption
+}
+
+func (ctx *Ctx) CreateTeam(r *CreateTeamRequest) (*Team, error) {
+	const m = "POST"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/orgs/%s/teams", ctx.ApiUrl, url.PathEscape(r.Org))
+	var res = new(Team)
+	return res, common.HttpRequest(m, u, &r.Opt, res, hdr, 201)
+}
+
+type ListTeamMembersRequest struct {
+	TeamID int
+	Page   int
+	Limit  int
+}
+
+func (ctx *Ctx) ListTeamMembers(r *ListTeamMembersRequest) ([]User, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/teams/%d/members?page=%d&limit=%d", ctx.ApiUrl, r.TeamID, r.Page, r.Limit)
+	var res []User
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+// same as ListTeamMembers but goes through all pages
+func (ctx *Ctx) ListAllTeamMembers(teamID int) ([]User, error) {
+	req := ListTeamMembersRequest{
+		TeamID: teamID,
+		Limit:  pageLimit,
+	}
+	var res []User
+	err := forEachPage(func(page int) (int, error) {
+		req.Page = page
+		u, err := ctx.ListTeamMembers(&req)
+		res = append(res, u...)
+		return len(u), err
+	})
+	return res, err
+}
+
+type TeamMemberRequest struct {
+	TeamID   int
+	Username string
+}
+
+func (ctx *Ctx) AddTeamMember(r *TeamMemberRequest) error {
+	const m = "PUT"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/teams/%d/members/%s", ctx.ApiUrl, r.TeamID, url.PathEscape(r.Username))
+	return common.HttpRequest(m, u, nil, nil, hdr, 204)
+}
+
+func (ctx *Ctx) RemoveTeamMember(r *TeamMemberRequest) error {
+	const m = "DELETE"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/teams/%d/members/%s", ctx.ApiUrl, r.TeamID, url.PathEscape(r.Username))
+	return common.HttpRequest(m, u, nil, nil, hdr, 204)
+}
+
+type ListTeamReposRequest struct {
+	TeamID int
+	Page   int
+	Limit  int
+}
+
+func (ctx *Ctx) ListTeamRepos(r *ListTeamReposRequest) ([]Repository, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/teams/%d/repos?page=%d&limit=%d", ctx.ApiUrl, r.TeamID, r.Page, r.Limit)
+	var res []Repository
+	return res, common.HttpRequest(m, u, nil, &res, hdr, 200)
+}
+
+// same as ListTeamRepos but goes through all pages
+func (ctx *Ctx) ListAllTeamRepos(teamID int) ([]Repository, error) {
+	req := ListTeamReposRequest{
+		TeamID: teamID,
+		Limit:  pageLimit,
+	}
+	var res []Repository
+	err := forEachPage(func(page int) (int, error) {
+		req.Page = page
+		r, err := ctx.ListTeamRepos(&req)
+		res = append(res, r...)
+		return len(r), err
+	})
+	return res, err
+}
+
+type TeamRepoRequest struct {
+	TeamID int
+	Org    string
+	Repo   string
+}
+
+// give team access to repository of organization
+func (ctx *Ctx) AddTeamRepo(r *TeamRepoRequest) error {
+	const m = "PUT"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/teams/%d/repos/%s/%s", ctx.ApiUrl, r.TeamID, url.PathEscape(r.Org), url.PathEscape(r.Repo))
+	return common.HttpRequest(m, u, nil, nil, hdr, 204)
+}
+
+func (ctx *Ctx) RemoveTeamRepo(r *TeamRepoRequest) error {
+	const m = "DELETE"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/teams/%d/repos/%s/%s", ctx.ApiUrl, r.TeamID, url.PathEscape(r.Org), url.PathEscape(r.Repo))
+	return common.HttpRequest(m, u, nil, nil, hdr, 204)
+}
@@ -0,0 +1,17 @@
+package gitea
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"net/http"
+)
+
+// user owning the token
+func (ctx *Ctx) GetCurrentUser() (*User, error) {
+	const m = "GET"
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "token "+ctx.Token)
+	var u = fmt.Sprintf("%s/user", ctx.ApiUrl)
+	var res = new(User)
+	return res, common.HttpRequest(m, u, nil, res, hdr, 200)
+}
@@ -0,0 +1,66 @@
+package gitea
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+type HookCommit struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	Url     string `json:"url"`
+	Author  struct {
+		Name     string `json:"name"`
+		Username string `json:"username"`
+	} `json:"author"`
+}
+
+type HookReview struct {
+	Type    string `json:"type"`
+	Content string `json:"content"`
+}
+
+/*
+payload delivered by gitea webhooks.
+fields are filled depending on event:
+pull_request, pull_request_review_*, issues, issue_comment,
+pull_request_comment, push, release or status
+*/
+type HookPayload struct {
+	Action      string       `json:"action"`
+	Number      int          `json:"number"`
+	PullRequest *PullRequest `json:"pull_request,omitempty"`
+	Issue       *Issue       `json:"issue,omitempty"`
+	Comment     *Comment     `json:"comment,omitempty"`
+	Review      *HookReview  `json:"review,omitempty"`
+	Release     *Release     `json:"release,omitempty"`
+	// set for review_requested action
+	RequestedReviewer *User       `json:"requested_reviewer,omitempty"`
+	Repository        *Repository `json:"repository,omitempty"`
+	Sender            *User       `json:"sender,omitempty"`
+
+	// push
+	Ref        string       `json:"ref"`
+	CompareUrl string       `json:"compare_url"`
+	Commits    []HookCommit `json:"commits"`
+	Pusher     *User        `json:"pusher,omitempty"`
+
+	// status
+	State       string `json:"state"`
+	Context     string `json:"context"`
+	Description string `json:"description"`
+	TargetUrl   string `json:"target_url"`
+	Sha         string `json:"sha"`
+}
+
+// check X-Gitea-Signature header, which is hex encoded hmac-sha256 of body
+func VerifyHookSignature(secret string, body []byte, signature string) bool {
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(sig, mac.Sum(nil))
+}
@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// matrix client-server api
+type Matrix struct {
+	// homeserver, eg. https://matrix.org
+	Url   string
+	Token string
+	// room id or #alias:server
+	Channel string
+}
+
+type matrixMsg struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format"`
+	FormattedBody string `json:"formatted_body"`
+}
+
+func (n *Matrix) header() http.Header {
+	hdr := make(http.Header)
+	hdr.Add("Authorization", "Bearer "+n.Token)
+	return hdr
+}
+
+// aliases have to be resolved to room id before sending
+func (n *Matrix) roomID(room string) (string, error) {
+	if !strings.HasPrefix(room, "#") {
+		return room, nil
+	}
+	var res struct {
+		RoomID string `json:"room_id"`
+	}
+	var u = fmt.Sprintf("%s/_matrix/client/v3/directory/room/%s", n.Url, url.PathEscape(room))
+	if err := common.HttpRequest("GET", u, nil, &res, n.header(), 200); err != nil {
+		return "", err
+	}
+	return res.RoomID, nil
+}
+
+// markdown links become anchors, everything else is escaped
+func matrixHtml(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range mdLinkRe.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:m[0]]))
+		fmt.Fprintf(&b, `<a href="%s">%s</a>`,
+			html.EscapeString(text[m[4]:m[5]]), html.EscapeString(text[m[2]:m[3]]))
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return strings.ReplaceAll(b.String(), "\n", "<br>")
+}
+
+func (n *Matrix) Notify(channel, text string) error {
+	room := pick(channel, n.Channel)
+	if room == "" {
+		return fmt.Errorf("no matrix room given")
+	}
+	room, err := n.roomID(room)
+	if err != nil {
+		return err
+	}
+	// transaction id makes retries idempotent, unique per message is enough
+	txn := strconv.FormatInt(time.Now().UnixNano(), 10)
+	var u = fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s", n.Url, url.PathEscape(room), txn)
+	return common.HttpRequest("PUT", u, &matrixMsg{
+		MsgType:       "m.text",
+		Body:          mdLinkRe.ReplaceAllString(text, "$1 ($2)"),
+		Format:        "org.matrix.custom.html",
+		FormattedBody: matrixHtml(text),
+	}, nil, n.header(), 200)
+}
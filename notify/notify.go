@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// posts markdown messages to chat
+type Notifier interface {
+	// empty channel means default channel of the target
+	Notify(channel, text string) error
+}
+
+func New(t *common.NotifyTarget, rc *common.Rocketchat) (Notifier, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	u := os.ExpandEnv(t.Url)
+	switch t.Type {
+	case common.NotifyRocketchat:
+		return newRocketchat(rc, t.Channel)
+	case common.NotifySlack:
+		return &Webhook{Url: u, Channel: t.Channel, Slack: true}, nil
+	case common.NotifyMattermost:
+		return &Webhook{Url: u, Channel: t.Channel}, nil
+	case common.NotifyMatrix:
+		return &Matrix{
+			Url:     strings.TrimSuffix(u, "/"),
+			Token:   os.ExpandEnv(t.Token),
+			Channel: t.Channel,
+		}, nil
+	case common.NotifyTeams:
+		return &Teams{Url: u}, nil
+	}
+	return nil, fmt.Errorf("unsupported notify target %s", t.Type)
+}
+
+func pick(channel, def string) string {
+	if channel != "" {
+		return channel
+	}
+	return def
+}
+
+var mdLinkRe = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]+)\)`)
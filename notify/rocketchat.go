@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"fmt"
+	"gitea-cli/common"
+	"gitea-cli/rocketchat"
+)
+
+type Rocketchat struct {
+	Ctx     *rocketchat.Ctx
+	Channel string
+}
+
+func newRocketchat(c *common.Rocketchat, channel string) (*Rocketchat, error) {
+	if !c.Enabled {
+		return nil, fmt.Errorf("rocketchat is not enabled in config")
+	}
+	if err := c.Validate(true); err != nil {
+		return nil, err
+	}
+	return &Rocketchat{
+		Ctx: &rocketchat.Ctx{
+			ApiUrl: c.ToApiUrl(),
+			UserID: c.UserID,
+			Token:  c.Token,
+		},
+		Channel: channel,
+	}, nil
+}
+
+func (n *Rocketchat) Notify(channel, text string) error {
+	channel = pick(channel, n.Channel)
+	if channel == "" {
+		return fmt.Errorf("no rocketchat channel given")
+	}
+	_, err := n.Ctx.PostMessage(&rocketchat.PostMsgRequest{
+		Channel: channel,
+		Text:    text,
+	})
+	return err
+}
@@ -0,0 +1,23 @@
+package notify
+
+import (
+	"gitea-cli/common"
+	"strings"
+)
+
+// microsoft teams incoming webhook, always posts to channel it was created for
+type Teams struct {
+	Url string
+}
+
+type teamsMsg struct {
+	Text string `json:"text"`
+}
+
+func (n *Teams) Notify(channel, text string) error {
+	// teams collapses single newlines
+	text = strings.ReplaceAll(text, "\n", "\n\n")
+	return common.HttpRequest("POST", n.Url, &teamsMsg{
+		Text: text,
+	}, nil, nil, 200)
+}
@@ -0,0 +1,29 @@
+package notify
+
+import (
+	"gitea-cli/common"
+)
+
+// slack or mattermost incoming webhook
+type Webhook struct {
+	Url string
+	// overrides channel of the webhook, can be empty
+	Channel string
+	// slack doesnt render markdown links
+	Slack bool
+}
+
+type webhookMsg struct {
+	Channel string `json:"channel,omitempty"`
+	Text    string `json:"text"`
+}
+
+func (n *Webhook) Notify(channel, text string) error {
+	if n.Slack {
+		text = mdLinkRe.ReplaceAllString(text, "<$2|$1>")
+	}
+	return common.HttpRequest("POST", n.Url, &webhookMsg{
+		Channel: pick(channel, n.Channel),
+		Text:    text,
+	}, nil, nil, 200)
+}